@@ -0,0 +1,86 @@
+/*
+Copyright 2022 NETCON developers.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DefaultPoolPolicyName is the name of the PoolPolicy applied to all Problems
+const DefaultPoolPolicyName = "default"
+
+// PoolPolicySpec defines the desired state of PoolPolicy
+type PoolPolicySpec struct {
+	// MaxReplicas is the upper limit of the total number of assignable ProblemEnvironments across Problems.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxReplicas *int `json:"maxReplicas,omitempty" yaml:"maxReplicas,omitempty"`
+
+	// MaxResources is the upper limit of the sum of resource requests of assignable
+	// ProblemEnvironments across Problems. Only cpu and memory are considered.
+	// +optional
+	MaxResources corev1.ResourceList `json:"maxResources,omitempty" yaml:"maxResources,omitempty"`
+
+	// Problems overrides the priority and the minimum of each Problem.
+	// Problems not listed here have priority 0 and no minimum.
+	// +optional
+	Problems []ProblemPoolPolicy `json:"problems,omitempty" yaml:"problems,omitempty"`
+}
+
+type ProblemPoolPolicy struct {
+	Namespace string `json:"namespace" yaml:"namespace"`
+	Name      string `json:"name" yaml:"name"`
+
+	// Priority decides the order to split the budget. Problems with higher priority
+	// get the budget first, and Problems with the same priority share the rest evenly.
+	// +optional
+	Priority int `json:"priority,omitempty" yaml:"priority,omitempty"`
+
+	// MinReplicas is allocated to the Problem before any Problem gets more than its minimum.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MinReplicas int `json:"minReplicas,omitempty" yaml:"minReplicas,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:resource:scope=Cluster,shortName={pool}
+//+kubebuilder:printcolumn:name=MAX-REPLICAS,type=integer,JSONPath=.spec.maxReplicas
+//+kubebuilder:printcolumn:name=Age,type=date,JSONPath=.metadata.creationTimestamp
+
+// PoolPolicy is the Schema for the poolpolicies API.
+// It splits the budget of assignable ProblemEnvironments among Problems.
+// Only the PoolPolicy named `default` is applied.
+type PoolPolicy struct {
+	metav1.TypeMeta   `json:",inline" yaml:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty" yaml:"metadata,omitempty"`
+
+	Spec PoolPolicySpec `json:"spec,omitempty" yaml:"spec,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// PoolPolicyList contains a list of PoolPolicy
+type PoolPolicyList struct {
+	metav1.TypeMeta `json:",inline" yaml:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty" yaml:"metadata,omitempty"`
+	Items           []PoolPolicy `json:"items" yaml:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&PoolPolicy{}, &PoolPolicyList{})
+}
@@ -17,14 +17,144 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"time"
+
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// LabelProblemName is the label set to ProblemEnvironments to refer the Problem they belong to
+const LabelProblemName = "problemName"
+
+type ProblemConditionType string
+
+const (
+	// Available will be True when:
+	// * enough ProblemEnvironments are assignable, allowing MaxUnavailable of RolloutStrategy
+	ProblemConditionAvailable ProblemConditionType = "Available"
+
+	// Progressing will be True when:
+	// * ProblemEnvironments are being created, deleted or replaced to meet the desired state
+	ProblemConditionProgressing ProblemConditionType = "Progressing"
+
+	// Degraded will be True when:
+	// * the last reconciliation failed, e.g. ProblemEnvironment couldn't be created
+	ProblemConditionDegraded ProblemConditionType = "Degraded"
+)
+
+const (
+	ProblemEventScaledUp     string = "ScaledUp"
+	ProblemEventScaledDown   string = "ScaledDown"
+	ProblemEventReplaced     string = "Replaced"
+	ProblemEventFailedCreate string = "FailedCreate"
+	ProblemEventFailedDelete string = "FailedDelete"
+)
+
+// LabelTemplateHash is the label set to ProblemEnvironments to identify the template
+// and the ConfigMaps they were created from
+const LabelTemplateHash = "netcon.janog.gr.jp/templateHash"
+
 // ProblemSpec defines the desired state of Problem
 type ProblemSpec struct {
 	Template *ProblemEnvironmentTemplate `json:"template" yaml:"template"`
 
 	AssignableReplicas int `json:"assignableReplicas" yaml:"assignableReplicas"`
+
+	// TopologySpreadConstraints describes how ProblemEnvironments of the Problem
+	// should be spread across Workers.
+	// +optional
+	TopologySpreadConstraints []TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty" yaml:"topologySpreadConstraints,omitempty"`
+
+	// RolloutStrategy describes how ProblemEnvironments not assigned are replaced
+	// when the template or the ConfigMaps referred by it are changed.
+	// ProblemEnvironments already assigned are never replaced.
+	// +optional
+	RolloutStrategy RolloutStrategy `json:"rolloutStrategy,omitempty" yaml:"rolloutStrategy,omitempty"`
+
+	// Autoscaling adjusts the number of assignable ProblemEnvironments to the demand.
+	// If Autoscaling is set, AssignableReplicas is ignored.
+	// +optional
+	Autoscaling *ProblemAutoscaling `json:"autoscaling,omitempty" yaml:"autoscaling,omitempty"`
+
+	// AssignmentTTL is the period for which ProblemEnvironments stay assigned after acquired
+	// or extended. Expired ProblemEnvironments are released and recycled.
+	// If AssignmentTTL is not set, assignments never expire.
+	// +optional
+	AssignmentTTL *metav1.Duration `json:"assignmentTTL,omitempty" yaml:"assignmentTTL,omitempty"`
+}
+
+// DefaultAcquisitionWindow is the window to count acquisitions when Autoscaling doesn't specify it
+const DefaultAcquisitionWindow = 10 * time.Minute
+
+type ProblemAutoscaling struct {
+	// MinReplicas is the lower limit of assignable ProblemEnvironments.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MinReplicas int `json:"minReplicas,omitempty" yaml:"minReplicas,omitempty"`
+
+	// MaxReplicas is the upper limit of assignable ProblemEnvironments.
+	// +kubebuilder:validation:Minimum=0
+	MaxReplicas int `json:"maxReplicas" yaml:"maxReplicas"`
+
+	// TargetFreeReplicas is the number of ProblemEnvironments kept assignable
+	// in addition to the ones expected to be acquired within Window.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	TargetFreeReplicas int `json:"targetFreeReplicas,omitempty" yaml:"targetFreeReplicas,omitempty"`
+
+	// ScaleUpLimit is the maximum number of replicas added per minute.
+	// 0 means unlimited.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	ScaleUpLimit int `json:"scaleUpLimit,omitempty" yaml:"scaleUpLimit,omitempty"`
+
+	// Window is the period to count acquisitions by gateway. Defaults to 10m.
+	// +optional
+	Window *metav1.Duration `json:"window,omitempty" yaml:"window,omitempty"`
+}
+
+type RolloutStrategy struct {
+	// MaxSurge is the maximum number of ProblemEnvironments not assigned which can be
+	// created over AssignableReplicas during rollout.
+	// If both MaxSurge and MaxUnavailable are 0, MaxSurge is considered to be 1.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxSurge int `json:"maxSurge,omitempty" yaml:"maxSurge,omitempty"`
+
+	// MaxUnavailable is the maximum number of assignable ProblemEnvironments which can be
+	// lacking from AssignableReplicas during rollout.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxUnavailable int `json:"maxUnavailable,omitempty" yaml:"maxUnavailable,omitempty"`
+}
+
+type UnsatisfiableConstraintAction string
+
+const (
+	// DoNotSchedule instructs the scheduler not to schedule ProblemEnvironments
+	// when the constraint can't be satisfied.
+	DoNotSchedule UnsatisfiableConstraintAction = "DoNotSchedule"
+
+	// ScheduleAnyway instructs the scheduler to schedule ProblemEnvironments anyway,
+	// but to prefer Workers which reduce the skew.
+	ScheduleAnyway UnsatisfiableConstraintAction = "ScheduleAnyway"
+)
+
+type TopologySpreadConstraint struct {
+	// MaxSkew is the maximum permitted difference between the number of
+	// ProblemEnvironments in any two domains.
+	// +kubebuilder:validation:Minimum=1
+	MaxSkew int `json:"maxSkew" yaml:"maxSkew"`
+
+	// TopologyKey is the key of Worker labels. Workers with the same value of
+	// the label are considered to be in the same domain.
+	// e.g. `netcon.janog.gr.jp/workerName` spreads ProblemEnvironments per Worker.
+	TopologyKey string `json:"topologyKey" yaml:"topologyKey"`
+
+	// WhenUnsatisfiable indicates how to deal with ProblemEnvironments which don't satisfy the constraint.
+	// +kubebuilder:validation:Enum=DoNotSchedule;ScheduleAnyway
+	// +kubebuilder:default=DoNotSchedule
+	// +optional
+	WhenUnsatisfiable UnsatisfiableConstraintAction `json:"whenUnsatisfiable,omitempty" yaml:"whenUnsatisfiable,omitempty"`
 }
 
 type ProblemEnvironmentTemplate struct {
@@ -35,7 +165,48 @@ type ProblemEnvironmentTemplate struct {
 
 // ProblemStatus defines the observed state of Problem
 type ProblemStatus struct {
+	// ObservedGeneration is the generation of the Problem observed by the controller
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty" yaml:"observedGeneration,omitempty"`
+
 	Replicas ProblemReplicas `json:"replicas" yaml:"replicas"`
+
+	// TemplateHash is the hash of the current template and the ConfigMaps referred by it
+	// +optional
+	TemplateHash string `json:"templateHash,omitempty" yaml:"templateHash,omitempty"`
+
+	// Acquisitions is recorded by gateway when ProblemEnvironments are acquired.
+	// +optional
+	Acquisitions ProblemAcquisitions `json:"acquisitions,omitempty" yaml:"acquisitions,omitempty"`
+
+	// DesiredReplicas is the number of assignable ProblemEnvironments decided by Autoscaling.
+	// +optional
+	DesiredReplicas int `json:"desiredReplicas,omitempty" yaml:"desiredReplicas,omitempty"`
+
+	// LastScaleUpTime is the last time when Autoscaling increased DesiredReplicas.
+	// +optional
+	LastScaleUpTime *metav1.Time `json:"lastScaleUpTime,omitempty" yaml:"lastScaleUpTime,omitempty"`
+
+	// AllocatedReplicas is the number of assignable ProblemEnvironments allocated by PoolPolicy.
+	// It's unset if PoolPolicy doesn't exist.
+	// +optional
+	AllocatedReplicas *int `json:"allocatedReplicas,omitempty" yaml:"allocatedReplicas,omitempty"`
+
+	Conditions []metav1.Condition `json:"conditions,omitempty" yaml:"conditions,omitempty"`
+}
+
+// ProblemAcquisitions counts acquisitions in fixed windows.
+// The rate is estimated from the current and the previous window.
+type ProblemAcquisitions struct {
+	// WindowStart is the start time of the current window
+	// +optional
+	WindowStart *metav1.Time `json:"windowStart,omitempty" yaml:"windowStart,omitempty"`
+
+	// Count is the number of acquisitions in the current window
+	Count int `json:"count" yaml:"count"`
+
+	// PreviousCount is the number of acquisitions in the previous window
+	PreviousCount int `json:"previousCount" yaml:"previousCount"`
 }
 
 type ProblemReplicas struct {
@@ -50,6 +221,12 @@ type ProblemReplicas struct {
 
 	// Assigned is the number of ProblemEnvironments which is assigned
 	Assigned int `json:"assigned" yaml:"assigned"`
+
+	// Updated is the number of ProblemEnvironments not assigned which is created from the current template
+	Updated int `json:"updated" yaml:"updated"`
+
+	// Outdated is the number of ProblemEnvironments not assigned which is waiting to be replaced
+	Outdated int `json:"outdated" yaml:"outdated"`
 }
 
 //+kubebuilder:object:root=true
@@ -59,7 +236,10 @@ type ProblemReplicas struct {
 //+kubebuilder:printcolumn:name=SCHEDULED,type=integer,JSONPath=.status.replicas.scheduled,priority=1
 //+kubebuilder:printcolumn:name=ASSIGNABLE,type=integer,JSONPath=.status.replicas.assignable
 //+kubebuilder:printcolumn:name=ASSIGNED,type=integer,JSONPath=.status.replicas.assigned,priority=1
+//+kubebuilder:printcolumn:name=UPDATED,type=integer,JSONPath=.status.replicas.updated,priority=1
+//+kubebuilder:printcolumn:name=OUTDATED,type=integer,JSONPath=.status.replicas.outdated,priority=1
 //+kubebuilder:printcolumn:name=TOTAL,type=integer,JSONPath=.status.replicas.total,priority=1
+//+kubebuilder:printcolumn:name=AVAILABLE,type=string,JSONPath=.status.conditions[?(@.type=="Available")].status
 //+kubebuilder:printcolumn:name=Age,type=date,JSONPath=.metadata.creationTimestamp
 
 // Problem is the Schema for the problems API
@@ -17,9 +17,27 @@ limitations under the License.
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// ProblemEnvironmentFinalizer is added by nclet to destroy the ProblemEnvironment on Worker before deleting it
+const ProblemEnvironmentFinalizer string = "problemenvironment.netcon.janog.gr.jp"
+
+// LabelAssignee is the label set to ProblemEnvironments by gateway, whose value is the ID of the assignee
+const LabelAssignee = "netcon.janog.gr.jp/assignee"
+
+// LabelDeployLogFor is the label set to deploy logs by nclet, whose value is the name of the ProblemEnvironment
+const LabelDeployLogFor = "netcon.janog.gr.jp/deployLogFor"
+
+// LabelDeployAttempt is the label set to deploy logs by nclet, whose value is the attempt to deploy
+// since ProblemEnvironment was created or reset
+const LabelDeployAttempt = "netcon.janog.gr.jp/deployAttempt"
+
+// LabelDeployResetGeneration is the label set to deploy logs by nclet, whose value is ObservedResetGeneration
+// of the ProblemEnvironment when it was deployed. It tells the same attempts before and after reset apart.
+const LabelDeployResetGeneration = "netcon.janog.gr.jp/deployResetGeneration"
+
 type ProblemEnvironmentConditionType string
 
 const (
@@ -39,15 +57,55 @@ const (
 	// Assigned will be True when:
 	// * ProblemEnvironment is assigned to some users
 	ProblemEnvironmentConditionAssigned ProblemEnvironmentConditionType = "Assigned"
+
+	// WorkerLost will be True when:
+	// * Worker where ProblemEnvironment is scheduled has been NotReady longer than the eviction grace period
+	// ProblemEnvironments not assigned are evicted instead.
+	ProblemEnvironmentConditionWorkerLost ProblemEnvironmentConditionType = "WorkerLost"
+
+	// AssignmentExpiring will be True when:
+	// * the assignment of ProblemEnvironment will expire soon
+	ProblemEnvironmentConditionAssignmentExpiring ProblemEnvironmentConditionType = "AssignmentExpiring"
+
+	// Resetting will be True when:
+	// * ProblemEnvironment is being destroyed and redeployed by nclet as reset is requested
+	ProblemEnvironmentConditionResetting ProblemEnvironmentConditionType = "Resetting"
+
+	// Failed will be True when:
+	// * nclet failed to deploy ProblemEnvironment as many times as the max attempts
+	// ProblemEnvironments not assigned are replaced by the Problem controller.
+	ProblemEnvironmentConditionFailed ProblemEnvironmentConditionType = "Failed"
+
+	// Hibernated will be True when:
+	// * the containers of ProblemEnvironment are paused as it has been idle longer than HibernateAfter
+	ProblemEnvironmentConditionHibernated ProblemEnvironmentConditionType = "Hibernated"
 )
 
 const (
-	ProblemEnvironmentEventScheduled string = "Scheduled"
-	ProblemEnvironmentEventDeploying string = "Deploying"
-	ProblemEnvironmentEventDeployed  string = "Deployed"
-	ProblemEnvironmentEventAssigned  string = "Assigned"
-	ProblemEnvironmentEventReady     string = "Ready"
-	ProblemEnvironmentEventNotReady  string = "NotReady"
+	ProblemEnvironmentEventScheduled  string = "Scheduled"
+	ProblemEnvironmentEventDeploying  string = "Deploying"
+	ProblemEnvironmentEventDeployed   string = "Deployed"
+	ProblemEnvironmentEventAssigned   string = "Assigned"
+	ProblemEnvironmentEventReady      string = "Ready"
+	ProblemEnvironmentEventNotReady   string = "NotReady"
+	ProblemEnvironmentEventWorkerLost string = "WorkerLost"
+
+	ProblemEnvironmentEventAssignmentExpiring string = "AssignmentExpiring"
+	ProblemEnvironmentEventAssignmentExpired  string = "AssignmentExpired"
+	ProblemEnvironmentEventAssignmentExtended string = "AssignmentExtended"
+
+	ProblemEnvironmentEventResetting   string = "Resetting"
+	ProblemEnvironmentEventReset       string = "Reset"
+	ProblemEnvironmentEventResetFailed string = "ResetFailed"
+
+	ProblemEnvironmentEventDeployFailed string = "DeployFailed"
+	ProblemEnvironmentEventFailed       string = "Failed"
+
+	ProblemEnvironmentEventContainerRestarted     string = "ContainerRestarted"
+	ProblemEnvironmentEventContainerRestartFailed string = "ContainerRestartFailed"
+
+	ProblemEnvironmentEventHibernated string = "Hibernated"
+	ProblemEnvironmentEventWokeUp     string = "WokeUp"
 )
 
 // ProblemEnvironmentSpec defines the desired state of ProblemEnvironment
@@ -62,6 +120,57 @@ type ProblemEnvironmentSpec struct {
 
 	// +optional
 	WorkerSelectors []metav1.LabelSelector `json:"workerSelectors,omitempty" yaml:"workerSelectors,omitempty"`
+
+	// Tolerations allow ProblemEnvironment to be scheduled on Workers with matching taints.
+	// +optional
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty" yaml:"tolerations,omitempty"`
+
+	// ResetGeneration is bumped by gateway or kubectl-netcon to reset ProblemEnvironment.
+	// nclet destroys and redeploys ProblemEnvironment with the same name and password
+	// when it differs from Status.ObservedResetGeneration.
+	// +optional
+	ResetGeneration int64 `json:"resetGeneration,omitempty" yaml:"resetGeneration,omitempty"`
+
+	// DeployTimeout is the timeout of each attempt to deploy ProblemEnvironment.
+	// It's usually set per Problem through the template. If it's not set, the default of nclet is used.
+	// +optional
+	DeployTimeout *metav1.Duration `json:"deployTimeout,omitempty" yaml:"deployTimeout,omitempty"`
+
+	// RestartPolicy is the policy to restart the containers exited after deployed.
+	// It's usually set per Problem through the template. If it's not set, containers are never restarted.
+	// +optional
+	RestartPolicy *RestartPolicy `json:"restartPolicy,omitempty" yaml:"restartPolicy,omitempty"`
+
+	// HibernateAfter is the idle period without SSH sessions after which nclet pauses the containers
+	// of ProblemEnvironment assigned. They are resumed on the next SSH login.
+	// It's usually set per Problem through the template. If it's not set, ProblemEnvironment never hibernates.
+	// +optional
+	HibernateAfter *metav1.Duration `json:"hibernateAfter,omitempty" yaml:"hibernateAfter,omitempty"`
+}
+
+// +kubebuilder:validation:Enum=Never;OnFailure
+type RestartPolicyType string
+
+const (
+	// RestartPolicyNever never restarts containers
+	RestartPolicyNever RestartPolicyType = "Never"
+
+	// RestartPolicyOnFailure restarts containers exited up to BackoffLimit times
+	RestartPolicyOnFailure RestartPolicyType = "OnFailure"
+)
+
+// DefaultRestartBackoffLimit is the default of BackoffLimit in RestartPolicy
+const DefaultRestartBackoffLimit = 3
+
+type RestartPolicy struct {
+	// +kubebuilder:default=Never
+	Type RestartPolicyType `json:"type" yaml:"type"`
+
+	// BackoffLimit is the number of restarts of each container before giving up.
+	// If it's not set, DefaultRestartBackoffLimit is used.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	BackoffLimit *int `json:"backoffLimit,omitempty" yaml:"backoffLimit,omitempty"`
 }
 
 type FileSource struct {
@@ -79,9 +188,47 @@ type ProblemEnvironmentStatus struct {
 
 	Password string `json:"password,omitempty" yaml:"password,omitempty"`
 
+	// ResourceRequests is the sum of CPU and memory of the nodes in the topology.
+	// It's calculated by controller-manager before scheduling.
+	// +optional
+	ResourceRequests corev1.ResourceList `json:"resourceRequests,omitempty" yaml:"resourceRequests,omitempty"`
+
+	// AssignmentExpiresAt is the time when the assignment expires.
+	// It's set by gateway when ProblemEnvironment is acquired or extended.
+	// +optional
+	AssignmentExpiresAt *metav1.Time `json:"assignmentExpiresAt,omitempty" yaml:"assignmentExpiresAt,omitempty"`
+
+	// Assignee is the team or user which ProblemEnvironment is assigned to.
+	// It's set by gateway when ProblemEnvironment is acquired.
+	// +optional
+	Assignee *Assignee `json:"assignee,omitempty" yaml:"assignee,omitempty"`
+
+	// ObservedResetGeneration is the ResetGeneration which nclet has reset ProblemEnvironment for.
+	// +optional
+	ObservedResetGeneration int64 `json:"observedResetGeneration,omitempty" yaml:"observedResetGeneration,omitempty"`
+
+	// DeployAttempts is the number of failed attempts to deploy ProblemEnvironment.
+	// It's reset by nclet when ProblemEnvironment is deployed successfully.
+	// +optional
+	DeployAttempts int `json:"deployAttempts,omitempty" yaml:"deployAttempts,omitempty"`
+
+	// LastDeployFailureTime is the time when nclet failed to deploy ProblemEnvironment last.
+	// +optional
+	LastDeployFailureTime *metav1.Time `json:"lastDeployFailureTime,omitempty" yaml:"lastDeployFailureTime,omitempty"`
+
 	Conditions []metav1.Condition `json:"conditions,omitempty" yaml:"conditions,omitempty"`
 }
 
+// Assignee identifies the team or user which ProblemEnvironment is assigned to.
+type Assignee struct {
+	// ID is the ID of the team or user. It's also set to LabelAssignee, so it must be a valid label value.
+	ID string `json:"id" yaml:"id"`
+
+	// Metadata is the arbitrary information about the assignee given by the score server.
+	// +optional
+	Metadata map[string]string `json:"metadata,omitempty" yaml:"metadata,omitempty"`
+}
+
 type ContainerStatus struct {
 	Name                string `json:"name" yaml:"name"`
 	Image               string `json:"image" yaml:"image"`
@@ -89,6 +236,14 @@ type ContainerStatus struct {
 	ContainerName       string `json:"containerName" yaml:"containerName"`
 	Ready               bool   `json:"ready" yaml:"ready"`
 	ManagementIPAddress string `json:"managementIPAddress" yaml:"managementIPAddress"`
+
+	// Running is true if the container is running, while Ready requires it to be usable
+	// +optional
+	Running bool `json:"running,omitempty" yaml:"running,omitempty"`
+
+	// RestartCount is the number of times nclet restarted the container after it exited
+	// +optional
+	RestartCount int `json:"restartCount,omitempty" yaml:"restartCount,omitempty"`
 }
 
 //+kubebuilder:object:root=true
@@ -98,6 +253,7 @@ type ContainerStatus struct {
 //+kubebuilder:printcolumn:name=DEPLOYED,type=string,JSONPath=.status.conditions[?(@.type=="Deployed")].status
 //+kubebuilder:printcolumn:name=READY,type=string,JSONPath=.status.conditions[?(@.type=="Ready")].status
 //+kubebuilder:printcolumn:name=ASSIGNED,type=string,JSONPath=.status.conditions[?(@.type=="Assigned")].status
+//+kubebuilder:printcolumn:name=ASSIGNEE,type=string,JSONPath=.status.assignee.id
 //+kubebuilder:printcolumn:name=WORKER,type=string,JSONPath=.spec.workerName,priority=1
 //+kubebuilder:printcolumn:name=PASSWORD,type=string,JSONPath=.status.password,priority=1
 //+kubebuilder:printcolumn:name=Age,type=date,JSONPath=.metadata.creationTimestamp
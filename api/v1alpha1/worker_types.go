@@ -17,6 +17,7 @@ limitations under the License.
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -24,20 +25,42 @@ type WorkerConditionType string
 
 const WorkerConditionReady WorkerConditionType = "Ready"
 
+const (
+	// LabelWorkerName is the label set to Workers by nclet, whose value is the name of the Worker
+	LabelWorkerName = "netcon.janog.gr.jp/workerName"
+
+	// LabelWorkerClass is the label set to Workers by nclet, whose value is the class of the Worker
+	LabelWorkerClass = "netcon.janog.gr.jp/workerClass"
+)
+
 const (
 	WorkerEventReady    string = "Ready"
 	WorkerEventNotReady string = "NotReady"
+	WorkerEventEvicted  string = "Evicted"
+
+	WorkerEventOrphanFound         string = "OrphanFound"
+	WorkerEventOrphanDestroyed     string = "OrphanDestroyed"
+	WorkerEventOrphanDestroyFailed string = "OrphanDestroyFailed"
 )
 
 // WorkerStatus defines the desired state of Worker
 type WorkerSpec struct {
 	DisableSchedule bool `json:"disableSchedule"`
+
+	// Taints repel ProblemEnvironments which don't tolerate them.
+	// NoExecute is treated as NoSchedule, as ProblemEnvironments are never evicted by taints.
+	// +optional
+	Taints []corev1.Taint `json:"taints,omitempty"`
 }
 
 // WorkerStatus defines the observed state of Worker
 type WorkerStatus struct {
 	WorkerInfo WorkerInfo `json:"workerInfo"`
 
+	// Images is the list of container images present on the Worker, reported by nclet.
+	// +optional
+	Images []corev1.ContainerImage `json:"images,omitempty"`
+
 	Conditions []metav1.Condition `json:"conditions,omitempty" yaml:"conditions,omitempty"`
 }
 
@@ -47,6 +70,10 @@ type WorkerInfo struct {
 	Hostname          string `json:"hostname"`
 	MemoryUsedPercent string `json:"memoryUsedPercent"`
 	CPUUsedPercent    string `json:"cpuUsedPercent"`
+
+	// Allocatable is the amount of CPU and memory which can be requested by ProblemEnvironments.
+	// +optional
+	Allocatable corev1.ResourceList `json:"allocatable,omitempty"`
 }
 
 //+kubebuilder:object:root=true
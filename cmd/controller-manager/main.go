@@ -26,10 +26,13 @@ import (
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 	"k8s.io/klog/v2"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	"sigs.k8s.io/controller-runtime/pkg/metrics/server"
@@ -38,6 +41,7 @@ import (
 	netconv1alpha1 "github.com/janog-netcon/netcon-problem-management-subsystem/api/v1alpha1"
 	controllers "github.com/janog-netcon/netcon-problem-management-subsystem/controllers/controller-manager"
 	"github.com/janog-netcon/netcon-problem-management-subsystem/pkg/log"
+	"github.com/janog-netcon/netcon-problem-management-subsystem/pkg/scheduler"
 )
 
 var (
@@ -60,6 +64,18 @@ func main() {
 		memoryWeight    float64
 		memoryThreshold float64
 		temperature     float64
+
+		maxPendingDeployments int
+
+		creationBurst int
+		creationQPS   float64
+
+		workerEvictionGracePeriod time.Duration
+
+		assignmentExpiryWarningPeriod time.Duration
+
+		schedulerConfigPath string
+		schedulerSeed       int64
 	)
 
 	loggerOpts := zap.Options{
@@ -78,6 +94,23 @@ func main() {
 	flag.Float64Var(&memoryWeight, "memory-weight", 3.0, "The weight of memory usage.")
 	flag.Float64Var(&memoryThreshold, "memory-threshold", 90.0, "The threshold of memory usage.")
 	flag.Float64Var(&temperature, "temperature", 0.1, "The temperature of the Boltzmann distribution.")
+	flag.IntVar(&maxPendingDeployments, "max-pending-deployments", 0,
+		"The maximum number of ProblemEnvironments being deployed on each Worker. 0 means unlimited.")
+	flag.IntVar(&creationBurst, "problem-environment-creation-burst", 10,
+		"The maximum number of ProblemEnvironments created at once for each Problem.")
+	flag.Float64Var(&creationQPS, "problem-environment-creation-qps", 5,
+		"The maximum number of ProblemEnvironments created per second. 0 means unlimited.")
+	flag.DurationVar(&workerEvictionGracePeriod, "worker-eviction-grace-period", 0,
+		"The period to wait before evicting ProblemEnvironments from NotReady Workers. 0 disables eviction.")
+	flag.DurationVar(&assignmentExpiryWarningPeriod, "assignment-expiry-warning-period", 10*time.Minute,
+		"The period before the assignment of ProblemEnvironments expires to warn with events.")
+	flag.StringVar(&schedulerConfigPath, "scheduler-config", "",
+		"The path to the scheduler config file. "+
+			"If set, --cpu-weight, --memory-weight, --memory-threshold, --temperature "+
+			"and --max-pending-deployments are ignored.")
+	flag.Int64Var(&schedulerSeed, "scheduler-seed", -1,
+		"The seed for the random election of Workers to make scheduling reproducible. "+
+			"A negative value means a random seed.")
 	loggerOpts.BindFlags(flag.CommandLine)
 	flag.Parse()
 
@@ -88,6 +121,11 @@ func main() {
 
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme: scheme,
+		Cache: cache.Options{
+			ByObject: map[client.Object]cache.ByObject{
+				&corev1.ConfigMap{}: {Label: controllers.ConfigMapCacheSelector()},
+			},
+		},
 		Metrics: server.Options{
 			BindAddress: metricsAddr,
 		},
@@ -114,9 +152,37 @@ func main() {
 		os.Exit(1)
 	}
 
+	var randomSource scheduler.RandomSource
+	if schedulerSeed >= 0 {
+		randomSource = scheduler.NewSeededRandomSource(uint64(schedulerSeed))
+	}
+
+	var schedulerFramework *scheduler.Framework
+	if schedulerConfigPath != "" {
+		schedulerConfig, err := scheduler.LoadConfig(schedulerConfigPath)
+		if err != nil {
+			setupLog.Error(err, "unable to load scheduler config")
+			os.Exit(1)
+		}
+
+		opts := []scheduler.Option{}
+		if randomSource != nil {
+			opts = append(opts, scheduler.WithRandomSource(randomSource))
+		}
+
+		schedulerFramework, err = scheduler.NewFramework(schedulerConfig, scheduler.NewInTreeRegistry(), opts...)
+		if err != nil {
+			setupLog.Error(err, "unable to create scheduler")
+			os.Exit(1)
+		}
+	}
+
 	if err = (&controllers.ProblemReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:        mgr.GetClient(),
+		Scheme:        mgr.GetScheme(),
+		Recorder:      mgr.GetEventRecorderFor("problem-controller"),
+		CreationBurst: creationBurst,
+		CreationQPS:   creationQPS,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Problem")
 		os.Exit(1)
@@ -130,15 +196,28 @@ func main() {
 			MemoryWeight:    memoryWeight,
 			MemoryThreshold: memoryThreshold,
 			Temperature:     temperature,
+
+			MaxPendingDeployments: maxPendingDeployments,
 		},
+		Scheduler:    schedulerFramework,
+		RandomSource: randomSource,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "ProblemEnvironment")
 		os.Exit(1)
 	}
+	if err = (&controllers.AssignmentReconciler{
+		Client:              mgr.GetClient(),
+		Recorder:            mgr.GetEventRecorderFor("assignment-controller"),
+		ExpiryWarningPeriod: assignmentExpiryWarningPeriod,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "Assignment")
+		os.Exit(1)
+	}
 
 	if err := mgr.Add(controllers.NewWorkerController(
 		mgr.GetClient(),
 		3*time.Second,
+		workerEvictionGracePeriod,
 		mgr.GetEventRecorderFor("worker-controller"),
 	)); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Worker")
@@ -30,6 +30,8 @@ import (
 
 	"github.com/docker/docker/client"
 	"github.com/shirou/gopsutil/v3/cpu"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
@@ -66,6 +68,22 @@ var (
 	workerClass string
 
 	maxWorkers int
+
+	maxDeployAttempts int
+	deployBackoff     string
+	deployTimeout     string
+
+	deployLogHistoryLimit int
+
+	driverName                   string
+	fakeDeployLatency            string
+	fakeDeployFailureProbability float64
+
+	orphanCheckInterval string
+	orphanGracePeriod   string
+
+	reservedCPU    string
+	reservedMemory string
 )
 
 func init() {
@@ -95,6 +113,30 @@ func main() {
 
 	flag.IntVar(&maxWorkers, "max-workers", 0, "Max workers for ProblemEnvironment")
 
+	flag.IntVar(&maxDeployAttempts, "max-deploy-attempts", controllers.DefaultMaxDeployAttempts,
+		"Attempts to deploy ProblemEnvironment before marking it as Failed")
+	flag.StringVar(&deployBackoff, "deploy-backoff", controllers.DefaultDeployBackoff.String(),
+		"Backoff before the first retry of deploying ProblemEnvironment, doubled for each failure")
+	flag.StringVar(&deployTimeout, "deploy-timeout", "15m",
+		"Timeout of deploying ProblemEnvironment unless it's set in ProblemEnvironment, 0 means no timeout")
+
+	flag.IntVar(&deployLogHistoryLimit, "deploy-log-history-limit", drivers.DefaultDeployLogHistoryLimit,
+		"Deploy logs kept per ProblemEnvironment, 0 means all of them are kept")
+
+	flag.StringVar(&driverName, "driver", "containerlab",
+		"The driver to deploy ProblemEnvironments, \"containerlab\" or \"fake\" which simulates them in memory for tests")
+	flag.StringVar(&fakeDeployLatency, "fake-deploy-latency", "0", "The period deploying takes with the fake driver")
+	flag.Float64Var(&fakeDeployFailureProbability, "fake-deploy-failure-probability", 0,
+		"The probability that deploying fails with the fake driver")
+
+	flag.StringVar(&orphanCheckInterval, "orphan-check-interval", "1m",
+		"Interval to check instances left on the Worker without ProblemEnvironment")
+	flag.StringVar(&orphanGracePeriod, "orphan-grace-period", "0",
+		"Period to wait before destroying instances without ProblemEnvironment, 0 means they are only reported")
+
+	flag.StringVar(&reservedCPU, "reserved-cpu", "1", "CPU reserved for the system, excluded from allocatable")
+	flag.StringVar(&reservedMemory, "reserved-memory", "2Gi", "Memory reserved for the system, excluded from allocatable")
+
 	opts := zap.Options{
 		Development: true,
 	}
@@ -134,7 +176,24 @@ func main() {
 		setupLog.Error(err, "failed to create docker client")
 	}
 
-	driver := drivers.NewContainerLabProblemEnvironmentDriver(configDir, dockerClient)
+	var driver drivers.ProblemEnvironmentDriver
+	switch driverName {
+	case "containerlab":
+		driver = drivers.NewContainerLabProblemEnvironmentDriver(configDir, dockerClient, deployLogHistoryLimit)
+	case "fake":
+		fakeDeployLatency, err := time.ParseDuration(fakeDeployLatency)
+		if err != nil {
+			setupLog.Error(err, "failed to parse fake deploy latency")
+			os.Exit(1)
+		}
+		driver = drivers.NewFakeProblemEnvironmentDriver(fakeDeployLatency, fakeDeployFailureProbability)
+	default:
+		setupLog.Error(fmt.Errorf("unknown driver: %s", driverName), "driver must be containerlab or fake")
+		os.Exit(1)
+	}
+
+	// shared between the reconciler and SSH server to hibernate idle ProblemEnvironments
+	sessions := controllers.NewSessionTracker()
 
 	workerName, err := os.Hostname()
 	if err != nil {
@@ -160,6 +219,30 @@ func main() {
 		setupLog.Error(err, "failed to status update interval")
 	}
 
+	deployBackoff, err := time.ParseDuration(deployBackoff)
+	if err != nil {
+		setupLog.Error(err, "failed to parse deploy backoff")
+		os.Exit(1)
+	}
+
+	deployTimeout, err := time.ParseDuration(deployTimeout)
+	if err != nil {
+		setupLog.Error(err, "failed to parse deploy timeout")
+		os.Exit(1)
+	}
+
+	orphanCheckInterval, err := time.ParseDuration(orphanCheckInterval)
+	if err != nil {
+		setupLog.Error(err, "failed to parse orphan check interval")
+		os.Exit(1)
+	}
+
+	orphanGracePeriod, err := time.ParseDuration(orphanGracePeriod)
+	if err != nil {
+		setupLog.Error(err, "failed to parse orphan grace period")
+		os.Exit(1)
+	}
+
 	idx := strings.LastIndex(sshAddr, ":")
 	if idx == -1 {
 		setupLog.Error(fmt.Errorf("invalid format"), "failed to parse sshAddr")
@@ -169,6 +252,19 @@ func main() {
 		setupLog.Error(err, "failed to parse sshAddr")
 	}
 
+	reserved := corev1.ResourceList{}
+	for name, value := range map[corev1.ResourceName]string{
+		corev1.ResourceCPU:    reservedCPU,
+		corev1.ResourceMemory: reservedMemory,
+	} {
+		quantity, err := resource.ParseQuantity(value)
+		if err != nil {
+			setupLog.Error(err, "failed to parse reserved resources", "resource", name)
+			os.Exit(1)
+		}
+		reserved[name] = quantity
+	}
+
 	if maxWorkers == 0 {
 		cores, err := cpu.Counts(true)
 		if err != nil {
@@ -185,18 +281,41 @@ func main() {
 		Scheme:                   mgr.GetScheme(),
 		Recorder:                 mgr.GetEventRecorderFor("problemenvironment-controller"),
 		MaxConcurrentReconciles:  maxWorkers,
+		MaxDeployAttempts:        maxDeployAttempts,
+		DeployBackoff:            deployBackoff,
+		DeployTimeout:            deployTimeout,
 		WorkerName:               workerName,
 		ProblemEnvironmentDriver: driver,
+		Sessions:                 sessions,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "ProblemEnvironment")
 		os.Exit(1)
 	}
 
-	if err = mgr.Add(controllers.NewSSHServer(mgr.GetClient(), sshAddr, adminPass)); err != nil {
+	if err = mgr.Add(controllers.NewSSHServer(
+		mgr.GetClient(),
+		mgr.GetEventRecorderFor("ssh-server"),
+		sshAddr,
+		adminPass,
+		sessions,
+		driver,
+	)); err != nil {
 		setupLog.Error(err, "unable to create ssh server")
 		os.Exit(1)
 	}
 
+	if err = mgr.Add(controllers.NewOrphanCollector(
+		mgr.GetClient(),
+		mgr.GetEventRecorderFor("orphan-collector"),
+		workerName,
+		orphanCheckInterval,
+		orphanGracePeriod,
+		driver,
+	)); err != nil {
+		setupLog.Error(err, "unable to add orphan collector")
+		os.Exit(1)
+	}
+
 	if err = mgr.Add(controllers.NewHeartbeatAgent(
 		mgr.GetClient(),
 		workerName,
@@ -205,6 +324,8 @@ func main() {
 		uint16(sshPort),
 		heartbeatInterval,
 		statusUpdateInterval,
+		reserved,
+		dockerClient,
 	)); err != nil {
 		setupLog.Error(err, "unable to add heartbeat agent")
 	}
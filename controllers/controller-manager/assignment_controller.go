@@ -0,0 +1,118 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	netconv1alpha1 "github.com/janog-netcon/netcon-problem-management-subsystem/api/v1alpha1"
+	"github.com/janog-netcon/netcon-problem-management-subsystem/pkg/util"
+)
+
+// AssignmentReconciler releases ProblemEnvironments whose assignment has expired.
+// Released ProblemEnvironments are deleted, and ProblemReconciler creates new ones instead.
+type AssignmentReconciler struct {
+	client.Client
+	Recorder record.EventRecorder
+
+	// ExpiryWarningPeriod is the period before expiry to warn with events
+	ExpiryWarningPeriod time.Duration
+}
+
+//+kubebuilder:rbac:groups=netcon.janog.gr.jp,resources=problemenvironments,verbs=get;list;watch;delete
+//+kubebuilder:rbac:groups=netcon.janog.gr.jp,resources=problemenvironments/status,verbs=get;update;patch
+
+func (r *AssignmentReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := log.FromContext(ctx)
+
+	problemEnvironment := netconv1alpha1.ProblemEnvironment{}
+	if err := r.Get(ctx, req.NamespacedName, &problemEnvironment); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if problemEnvironment.DeletionTimestamp != nil {
+		return ctrl.Result{}, nil
+	}
+
+	if util.GetProblemEnvironmentCondition(
+		&problemEnvironment,
+		netconv1alpha1.ProblemEnvironmentConditionAssigned,
+	) != metav1.ConditionTrue {
+		return ctrl.Result{}, nil
+	}
+
+	expiresAt := problemEnvironment.Status.AssignmentExpiresAt
+	if expiresAt == nil {
+		return ctrl.Result{}, nil
+	}
+
+	remaining := time.Until(expiresAt.Time)
+	if remaining <= 0 {
+		r.Recorder.Event(
+			&problemEnvironment,
+			corev1.EventTypeWarning,
+			netconv1alpha1.ProblemEnvironmentEventAssignmentExpired,
+			"Assignment expired, releasing ProblemEnvironment",
+		)
+
+		log.Info("releasing ProblemEnvironment as assignment expired")
+		return ctrl.Result{}, client.IgnoreNotFound(r.Delete(ctx, &problemEnvironment))
+	}
+
+	expiring := util.GetProblemEnvironmentCondition(
+		&problemEnvironment,
+		netconv1alpha1.ProblemEnvironmentConditionAssignmentExpiring,
+	) == metav1.ConditionTrue
+
+	if remaining > r.ExpiryWarningPeriod {
+		// the assignment may have been extended after warned
+		if expiring {
+			util.SetProblemEnvironmentCondition(
+				&problemEnvironment,
+				netconv1alpha1.ProblemEnvironmentConditionAssignmentExpiring,
+				metav1.ConditionFalse,
+				"Extended", "Assignment was extended",
+			)
+			if err := r.Status().Update(ctx, &problemEnvironment); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+		return ctrl.Result{RequeueAfter: remaining - r.ExpiryWarningPeriod}, nil
+	}
+
+	if !expiring {
+		message := "Assignment expires in " + duration.HumanDuration(remaining)
+		r.Recorder.Event(
+			&problemEnvironment,
+			corev1.EventTypeWarning,
+			netconv1alpha1.ProblemEnvironmentEventAssignmentExpiring,
+			message,
+		)
+
+		util.SetProblemEnvironmentCondition(
+			&problemEnvironment,
+			netconv1alpha1.ProblemEnvironmentConditionAssignmentExpiring,
+			metav1.ConditionTrue,
+			"Expiring", message,
+		)
+		if err := r.Status().Update(ctx, &problemEnvironment); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	return ctrl.Result{RequeueAfter: remaining}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *AssignmentReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("assignment").
+		For(&netconv1alpha1.ProblemEnvironment{}).
+		Complete(r)
+}
@@ -0,0 +1,100 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	netconv1alpha1 "github.com/janog-netcon/netcon-problem-management-subsystem/api/v1alpha1"
+	util "github.com/janog-netcon/netcon-problem-management-subsystem/pkg/util"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics/server"
+)
+
+var _ = Describe("Assignment controller", func() {
+	ctx := context.Background()
+
+	var stopFunc func()
+
+	BeforeEach(func() {
+		err := k8sClient.DeleteAllOf(ctx, &netconv1alpha1.ProblemEnvironment{}, client.InNamespace("default"))
+		Expect(err).ToNot(HaveOccurred())
+		time.Sleep(100 * time.Millisecond)
+
+		mgr, err := ctrl.NewManager(cfg, ctrl.Options{
+			Scheme: scheme.Scheme,
+			Metrics: server.Options{
+				BindAddress: "0",
+			},
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		err = (&AssignmentReconciler{
+			Client:              k8sClient,
+			Recorder:            mgr.GetEventRecorderFor("assignment-controller"),
+			ExpiryWarningPeriod: time.Hour,
+		}).SetupWithManager(mgr)
+		Expect(err).NotTo(HaveOccurred())
+
+		ctx, cancel := context.WithCancel(ctx)
+		stopFunc = cancel
+		go func() {
+			err := mgr.Start(ctx)
+			if err != nil {
+				panic(err)
+			}
+		}()
+		time.Sleep(100 * time.Millisecond)
+	})
+
+	AfterEach(func() {
+		stopFunc()
+		time.Sleep(100 * time.Millisecond)
+	})
+
+	It("should warn and release ProblemEnvironment when the assignment expires", func() {
+		problemEnvironment := netconv1alpha1.ProblemEnvironment{}
+		err := loadManifest(filepath.Join("tests", "problemenvironments", "problemenvironment-tst-001.yaml"), &problemEnvironment)
+		Expect(err).NotTo(HaveOccurred())
+
+		err = k8sClient.Create(ctx, &problemEnvironment)
+		Expect(err).NotTo(HaveOccurred())
+
+		expiresAt := metav1.NewTime(time.Now().Add(3 * time.Second))
+		problemEnvironment.Status.AssignmentExpiresAt = &expiresAt
+		util.SetProblemEnvironmentCondition(
+			&problemEnvironment,
+			netconv1alpha1.ProblemEnvironmentConditionAssigned,
+			metav1.ConditionTrue,
+			"Test", "test",
+		)
+		err = k8sClient.Status().Update(ctx, &problemEnvironment)
+		Expect(err).NotTo(HaveOccurred())
+
+		// it expires within ExpiryWarningPeriod, so it's warned first
+		Eventually(func() error {
+			if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(&problemEnvironment), &problemEnvironment); err != nil {
+				return err
+			}
+			if util.GetProblemEnvironmentCondition(
+				&problemEnvironment,
+				netconv1alpha1.ProblemEnvironmentConditionAssignmentExpiring,
+			) != metav1.ConditionTrue {
+				return fmt.Errorf("AssignmentExpiring is not True")
+			}
+			return nil
+		}).Should(Succeed())
+
+		Eventually(func() bool {
+			err := k8sClient.Get(ctx, client.ObjectKeyFromObject(&problemEnvironment), &problemEnvironment)
+			return apierrors.IsNotFound(err)
+		}, 10*time.Second).Should(BeTrue())
+	})
+})
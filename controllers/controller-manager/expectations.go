@@ -0,0 +1,71 @@
+package controllers
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	netconv1alpha1 "github.com/janog-netcon/netcon-problem-management-subsystem/api/v1alpha1"
+)
+
+// creationExpectationsTimeout is the period to wait for ProblemEnvironments created to be observed.
+// It prevents ProblemReconciler from being blocked forever when they are deleted before observed.
+const creationExpectationsTimeout = 5 * time.Minute
+
+// creationExpectations remembers ProblemEnvironments created by ProblemReconciler until
+// they are observed in the cache. Without it, ProblemReconciler can create
+// ProblemEnvironments again as the cache doesn't contain the ones created just before.
+type creationExpectations struct {
+	mu sync.Mutex
+
+	// pending holds the time when each ProblemEnvironment was created, keyed by Problem
+	pending map[types.NamespacedName]map[string]time.Time
+}
+
+func newCreationExpectations() *creationExpectations {
+	return &creationExpectations{
+		pending: map[types.NamespacedName]map[string]time.Time{},
+	}
+}
+
+// expect records the ProblemEnvironment created for the Problem.
+func (e *creationExpectations) expect(problem types.NamespacedName, name string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if _, ok := e.pending[problem]; !ok {
+		e.pending[problem] = map[string]time.Time{}
+	}
+	e.pending[problem][name] = time.Now()
+}
+
+// satisfied returns true if all ProblemEnvironments created for the Problem are observed.
+// Observed or expired ones are forgotten.
+func (e *creationExpectations) satisfied(
+	problem types.NamespacedName,
+	observed []netconv1alpha1.ProblemEnvironment,
+) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	pending, ok := e.pending[problem]
+	if !ok {
+		return true
+	}
+
+	for _, problemEnvironment := range observed {
+		delete(pending, problemEnvironment.Name)
+	}
+	for name, createdAt := range pending {
+		if time.Since(createdAt) > creationExpectationsTimeout {
+			delete(pending, name)
+		}
+	}
+
+	if len(pending) == 0 {
+		delete(e.pending, problem)
+		return true
+	}
+	return false
+}
@@ -0,0 +1,133 @@
+package controllers
+
+import (
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	netconv1alpha1 "github.com/janog-netcon/netcon-problem-management-subsystem/api/v1alpha1"
+)
+
+// poolDemand is the number of assignable ProblemEnvironments a Problem desires.
+type poolDemand struct {
+	key         types.NamespacedName
+	replicas    int
+	priority    int
+	minReplicas int
+
+	// resourceRequests is the resource requests of each ProblemEnvironment of the Problem
+	resourceRequests corev1.ResourceList
+}
+
+// poolBudget is the rest of the budget of PoolPolicy.
+type poolBudget struct {
+	// replicas is the number of ProblemEnvironments which can be allocated, or -1 if unlimited
+	replicas  int
+	resources corev1.ResourceList
+}
+
+func newPoolBudget(policy *netconv1alpha1.PoolPolicy) *poolBudget {
+	budget := &poolBudget{replicas: -1, resources: corev1.ResourceList{}}
+	if policy.Spec.MaxReplicas != nil {
+		budget.replicas = *policy.Spec.MaxReplicas
+	}
+	for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		if quantity, ok := policy.Spec.MaxResources[name]; ok {
+			budget.resources[name] = quantity.DeepCopy()
+		}
+	}
+	return budget
+}
+
+// take consumes the budget for a ProblemEnvironment of the demand.
+// It returns false without consuming anything if the budget is not enough.
+func (b *poolBudget) take(demand *poolDemand) bool {
+	if b.replicas == 0 {
+		return false
+	}
+	for name, rest := range b.resources {
+		if request, ok := demand.resourceRequests[name]; ok && rest.Cmp(request) < 0 {
+			return false
+		}
+	}
+
+	if b.replicas > 0 {
+		b.replicas--
+	}
+	for name, rest := range b.resources {
+		if request, ok := demand.resourceRequests[name]; ok {
+			rest.Sub(request)
+			b.resources[name] = rest
+		}
+	}
+	return true
+}
+
+// newPoolDemand returns poolDemand of the Problem with the priority and the minimum in PoolPolicy.
+func newPoolDemand(
+	policy *netconv1alpha1.PoolPolicy,
+	problem *netconv1alpha1.Problem,
+	replicas int,
+	resourceRequests corev1.ResourceList,
+) poolDemand {
+	demand := poolDemand{
+		key:              types.NamespacedName{Namespace: problem.Namespace, Name: problem.Name},
+		replicas:         replicas,
+		resourceRequests: resourceRequests,
+	}
+	for _, p := range policy.Spec.Problems {
+		if p.Namespace == problem.Namespace && p.Name == problem.Name {
+			demand.priority = p.Priority
+			demand.minReplicas = p.MinReplicas
+			break
+		}
+	}
+	return demand
+}
+
+// allocatePool splits the budget of PoolPolicy among Problems.
+//
+// First, the minimum of each Problem is allocated in the order of priority.
+// Then, the rest is allocated to Problems with higher priority first. Problems
+// with the same priority get ProblemEnvironments one by one in turn.
+func allocatePool(policy *netconv1alpha1.PoolPolicy, demands []poolDemand) map[types.NamespacedName]int {
+	sort.SliceStable(demands, func(i, j int) bool {
+		if demands[i].priority != demands[j].priority {
+			return demands[i].priority > demands[j].priority
+		}
+		return demands[i].key.String() < demands[j].key.String()
+	})
+
+	budget := newPoolBudget(policy)
+	allocated := map[types.NamespacedName]int{}
+
+	for i := range demands {
+		demand := &demands[i]
+		for allocated[demand.key] < min(demand.minReplicas, demand.replicas) && budget.take(demand) {
+			allocated[demand.key]++
+		}
+	}
+
+	for start := 0; start < len(demands); {
+		end := start
+		for end < len(demands) && demands[end].priority == demands[start].priority {
+			end++
+		}
+
+		for progress := true; progress; {
+			progress = false
+			for i := start; i < end; i++ {
+				demand := &demands[i]
+				if allocated[demand.key] < demand.replicas && budget.take(demand) {
+					allocated[demand.key]++
+					progress = true
+				}
+			}
+		}
+
+		start = end
+	}
+
+	return allocated
+}
@@ -18,31 +18,75 @@ package controllers
 
 import (
 	"context"
-
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"sort"
+	"time"
+
+	"golang.org/x/time/rate"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	netconv1alpha1 "github.com/janog-netcon/netcon-problem-management-subsystem/api/v1alpha1"
 	util "github.com/janog-netcon/netcon-problem-management-subsystem/pkg/util"
 )
 
 const (
-	KeyProblemName = "problemName"
+	KeyProblemName = netconv1alpha1.LabelProblemName
+)
+
+const (
+	// autoscalingInterval is the interval to re-evaluate Autoscaling of Problems
+	autoscalingInterval = 30 * time.Second
+
+	// scaleUpLimitPeriod is the period which ScaleUpLimit of Autoscaling is applied to
+	scaleUpLimitPeriod = time.Minute
 )
 
 // ProblemReconciler reconciles a Problem object
 type ProblemReconciler struct {
 	client.Client
-	Scheme *runtime.Scheme
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+
+	// CreationBurst is the maximum number of ProblemEnvironments created at once.
+	// If CreationBurst is 0, ProblemEnvironments are created one by one.
+	CreationBurst int
+
+	// CreationQPS is the maximum number of ProblemEnvironments created per second
+	// across all Problems. If CreationQPS is 0, it's unlimited.
+	CreationQPS float64
+
+	limiter      *rate.Limiter
+	expectations *creationExpectations
 }
 
 //+kubebuilder:rbac:groups=netcon.janog.gr.jp,resources=problems,verbs=get;list;watch;update;patch
 //+kubebuilder:rbac:groups=netcon.janog.gr.jp,resources=problemenvironments,verbs=get;list;watch;create;delete
 //+kubebuilder:rbac:groups=netcon.janog.gr.jp,resources=problems/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch
+//+kubebuilder:rbac:groups=netcon.janog.gr.jp,resources=poolpolicies,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 
 func (r *ProblemReconciler) listChildProblemEnvironments(
 	ctx context.Context,
@@ -73,7 +117,7 @@ func (r *ProblemReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 	}
 
 	if !problem.DeletionTimestamp.IsZero() {
-		return r.updateStatus(ctx, &problem)
+		return r.updateStatus(ctx, &problem, nil)
 	}
 
 	problemEnvironments, err := r.listChildProblemEnvironments(ctx, &problem)
@@ -82,7 +126,14 @@ func (r *ProblemReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		return ctrl.Result{}, nil
 	}
 
-	assignableProblemEnvironments := 0
+	templateHash, err := r.computeTemplateHash(ctx, &problem)
+	if err != nil {
+		log.Error(err, "failed to compute template hash")
+		return r.updateStatus(ctx, &problem, err)
+	}
+	problem.Status.TemplateHash = templateHash
+
+	var updated, outdated, failed []netconv1alpha1.ProblemEnvironment
 	for _, problemEnvironment := range problemEnvironments.Items {
 		// problemEnvironment being deleted is not assignable
 		if problemEnvironment.DeletionTimestamp != nil {
@@ -97,67 +148,381 @@ func (r *ProblemReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 			continue
 		}
 
+		// problemEnvironment nclet gave up deploying is never assignable, so it's replaced
+		if util.GetProblemEnvironmentCondition(
+			&problemEnvironment,
+			netconv1alpha1.ProblemEnvironmentConditionFailed,
+		) == metav1.ConditionTrue {
+			failed = append(failed, problemEnvironment)
+			continue
+		}
+
 		// otherwise problemEnvironment is assignable
-		assignableProblemEnvironments = assignableProblemEnvironments + 1
+		if isOutdated(&problemEnvironment, templateHash) {
+			outdated = append(outdated, problemEnvironment)
+		} else {
+			updated = append(updated, problemEnvironment)
+		}
 	}
 
-	if problem.Spec.AssignableReplicas > assignableProblemEnvironments {
-		newProbEnv := netconv1alpha1.ProblemEnvironment{}
-
-		template := *problem.Spec.Template.DeepCopy()
+	for _, pe := range failed {
+		if err := r.Delete(ctx, &pe); err != nil {
+			r.Recorder.Eventf(&problem, corev1.EventTypeWarning, netconv1alpha1.ProblemEventFailedDelete,
+				"Failed to delete ProblemEnvironment %s: %s", pe.Name, err)
+			return r.updateStatus(ctx, &problem, err)
+		}
+		log.Info("deleted failed ProblemEnvironment", "name", pe.Name)
+		r.Recorder.Eventf(&problem, corev1.EventTypeWarning, netconv1alpha1.ProblemEventReplaced,
+			"Deleted ProblemEnvironment %s failed to deploy", pe.Name)
+	}
 
-		newProbEnv.Labels = template.Labels
-		newProbEnv.Annotations = template.Annotations
-		newProbEnv.Namespace = problem.Namespace
-		newProbEnv.GenerateName = problem.Name + "-"
-		newProbEnv.Spec = template.Spec
+	if problem.Spec.Autoscaling != nil {
+		r.autoscale(ctx, &problem, time.Now())
+	}
 
-		if newProbEnv.Labels == nil {
-			newProbEnv.Labels = make(map[string]string)
+	allocatedReplicas, err := r.allocateFromPool(ctx, &problem)
+	if err != nil {
+		log.Error(err, "failed to allocate from PoolPolicy")
+		return r.updateStatus(ctx, &problem, err)
+	}
+	problem.Status.AllocatedReplicas = allocatedReplicas
+
+	assignableReplicas := desiredAssignableReplicas(&problem)
+	maxSurge, maxUnavailable := rolloutParameters(&problem)
+
+	var requeueAfter time.Duration
+	if assignableReplicas > len(updated) {
+		// outdated ProblemEnvironments are kept until they are replaced, so that
+		// ProblemEnvironments can be assigned during rollout
+		toCreate := min(
+			assignableReplicas-len(updated),
+			assignableReplicas+maxSurge-len(updated)-len(outdated),
+			max(r.CreationBurst, 1),
+		)
+
+		if toCreate > 0 && !r.expectations.satisfied(req.NamespacedName, problemEnvironments.Items) {
+			// the cache will be updated soon, and then Problem will be reconciled again
+			log.V(1).Info("waiting for ProblemEnvironments created to be observed")
+			toCreate = 0
 		}
-		newProbEnv.Labels[KeyProblemName] = problem.Name
 
-		if err := controllerutil.SetControllerReference(&problem, &newProbEnv, r.Scheme); err != nil {
-			return ctrl.Result{}, err
+		created := 0
+		for range toCreate {
+			if r.limiter != nil && !r.limiter.Allow() {
+				requeueAfter = time.Duration(float64(time.Second) / r.CreationQPS)
+				break
+			}
+
+			name, err := r.createProblemEnvironment(ctx, &problem, templateHash)
+			if err != nil {
+				log.Error(err, "could not create new ProblemEnvironment")
+				r.Recorder.Eventf(&problem, corev1.EventTypeWarning, netconv1alpha1.ProblemEventFailedCreate,
+					"Failed to create ProblemEnvironment: %s", err)
+				return r.updateStatus(ctx, &problem, err)
+			}
+			r.expectations.expect(req.NamespacedName, name)
+			log.Info("created ProblemEnvironment", "name", name)
+			created++
 		}
 
-		if err := r.Create(ctx, &newProbEnv); err != nil {
-			log.Error(err, "could not create new ProblemEnvironment")
-			return ctrl.Result{}, err
+		if created > 0 {
+			r.Recorder.Eventf(&problem, corev1.EventTypeNormal, netconv1alpha1.ProblemEventScaledUp,
+				"Created %d ProblemEnvironments", created)
 		}
+	} else if assignableReplicas < len(updated) {
+		diff := len(updated) - assignableReplicas
+		updated = sortForDeletion(updated)
+		for _, pe := range updated[:diff] {
+			if err := r.Delete(ctx, &pe); err != nil {
+				r.Recorder.Eventf(&problem, corev1.EventTypeWarning, netconv1alpha1.ProblemEventFailedDelete,
+					"Failed to delete ProblemEnvironment %s: %s", pe.Name, err)
+				return r.updateStatus(ctx, &problem, err)
+			}
+			log.Info("deleted ProblemEnvironment", "name", pe.Name)
+		}
+		updated = updated[diff:]
 
-		log.Info("created ProblemEnvironment")
-	} else if problem.Spec.AssignableReplicas < assignableProblemEnvironments {
-		diff := assignableProblemEnvironments - problem.Spec.AssignableReplicas
-		delete_count := 0
-		for _, pe := range problemEnvironments.Items {
-			if delete_count >= diff {
+		r.Recorder.Eventf(&problem, corev1.EventTypeNormal, netconv1alpha1.ProblemEventScaledDown,
+			"Deleted %d ProblemEnvironments", diff)
+	}
+
+	// outdated ProblemEnvironments are deleted as long as enough ProblemEnvironments are
+	// ready to be assigned.
+	minAvailable := assignableReplicas - maxUnavailable
+	available := countReady(updated) + countReady(outdated)
+	for _, pe := range sortForDeletion(outdated) {
+		if isReady(&pe) {
+			if available <= minAvailable {
 				break
 			}
-			condition := util.GetProblemEnvironmentCondition(&pe, netconv1alpha1.ProblemEnvironmentConditionAssigned)
-			if condition != metav1.ConditionTrue {
-				if err := r.Delete(ctx, &pe); err != nil {
-					return ctrl.Result{}, err
-				}
-				delete_count += 1
-				log.Info("deleted ProblemEnvironment")
+			available--
+		}
+
+		if err := r.Delete(ctx, &pe); err != nil {
+			r.Recorder.Eventf(&problem, corev1.EventTypeWarning, netconv1alpha1.ProblemEventFailedDelete,
+				"Failed to delete ProblemEnvironment %s: %s", pe.Name, err)
+			return r.updateStatus(ctx, &problem, err)
+		}
+		log.Info("deleted outdated ProblemEnvironment", "name", pe.Name)
+		r.Recorder.Eventf(&problem, corev1.EventTypeNormal, netconv1alpha1.ProblemEventReplaced,
+			"Deleted outdated ProblemEnvironment %s", pe.Name)
+	}
+
+	result, err := r.updateStatus(ctx, &problem, nil)
+	if err == nil && problem.Spec.Autoscaling != nil {
+		// the estimated demand decays even if nothing happens
+		if requeueAfter == 0 || requeueAfter > autoscalingInterval {
+			requeueAfter = autoscalingInterval
+		}
+	}
+	if err == nil && requeueAfter > 0 {
+		result.RequeueAfter = requeueAfter
+	}
+	return result, err
+}
+
+// autoscale decides the number of assignable ProblemEnvironments from the acquisitions
+// recorded by gateway, and stores it to the status.
+func (r *ProblemReconciler) autoscale(ctx context.Context, problem *netconv1alpha1.Problem, now time.Time) {
+	log := log.FromContext(ctx)
+
+	autoscaling := problem.Spec.Autoscaling
+	current := problem.Status.DesiredReplicas
+
+	estimated := util.EstimateProblemAcquisitions(problem, now)
+	desired := int(math.Ceil(estimated)) + autoscaling.TargetFreeReplicas
+	desired = min(desired, autoscaling.MaxReplicas)
+
+	if desired > current && autoscaling.ScaleUpLimit > 0 {
+		lastScaleUpTime := problem.Status.LastScaleUpTime
+		if lastScaleUpTime != nil && now.Sub(lastScaleUpTime.Time) < scaleUpLimitPeriod {
+			desired = current
+		} else {
+			desired = min(desired, current+autoscaling.ScaleUpLimit)
+		}
+	}
+	desired = max(desired, autoscaling.MinReplicas)
+
+	if desired == current {
+		return
+	}
+
+	if desired > current {
+		lastScaleUpTime := metav1.NewTime(now)
+		problem.Status.LastScaleUpTime = &lastScaleUpTime
+	}
+	problem.Status.DesiredReplicas = desired
+
+	log.Info("autoscaled assignable replicas", "from", current, "to", desired, "estimatedAcquisitions", estimated)
+}
+
+// allocateFromPool returns the number of assignable ProblemEnvironments allocated to the Problem
+// by PoolPolicy, or nil if PoolPolicy doesn't exist.
+func (r *ProblemReconciler) allocateFromPool(ctx context.Context, problem *netconv1alpha1.Problem) (*int, error) {
+	log := log.FromContext(ctx)
+
+	policy := netconv1alpha1.PoolPolicy{}
+	if err := r.Get(ctx, types.NamespacedName{Name: netconv1alpha1.DefaultPoolPolicyName}, &policy); err != nil {
+		return nil, client.IgnoreNotFound(err)
+	}
+
+	problems := netconv1alpha1.ProblemList{}
+	if err := r.List(ctx, &problems); err != nil {
+		return nil, err
+	}
+
+	demands := []poolDemand{}
+	for i := range problems.Items {
+		p := &problems.Items[i]
+		if p.Namespace == problem.Namespace && p.Name == problem.Name {
+			// use the one being reconciled as it has the latest DesiredReplicas
+			p = problem
+		}
+		if p.DeletionTimestamp != nil {
+			continue
+		}
+
+		var resourceRequests corev1.ResourceList
+		if len(policy.Spec.MaxResources) != 0 && p.Spec.Template != nil {
+			topology, err := loadTopology(ctx, r.Client, p.Namespace, p.Spec.Template.Spec.TopologyFile)
+			if err == nil {
+				resourceRequests, err = topology.ResourceRequests()
+			}
+			if err != nil {
+				log.Error(err, "failed to calculate resource requests, considering it requests nothing", "problem", p.Name)
 			}
 		}
+
+		demands = append(demands, newPoolDemand(&policy, p, util.GetAssignableReplicas(p), resourceRequests))
 	}
 
-	return r.updateStatus(ctx, &problem)
+	allocated := allocatePool(&policy, demands)[client.ObjectKeyFromObject(problem)]
+	return &allocated, nil
 }
 
-func (r *ProblemReconciler) updateStatus(ctx context.Context, problem *netconv1alpha1.Problem) (ctrl.Result, error) {
+func (r *ProblemReconciler) createProblemEnvironment(
+	ctx context.Context,
+	problem *netconv1alpha1.Problem,
+	templateHash string,
+) (string, error) {
+	newProbEnv := netconv1alpha1.ProblemEnvironment{}
+
+	template := *problem.Spec.Template.DeepCopy()
+
+	newProbEnv.Labels = template.Labels
+	newProbEnv.Annotations = template.Annotations
+	newProbEnv.Namespace = problem.Namespace
+	newProbEnv.GenerateName = problem.Name + "-"
+	newProbEnv.Spec = template.Spec
+
+	if newProbEnv.Labels == nil {
+		newProbEnv.Labels = make(map[string]string)
+	}
+	newProbEnv.Labels[KeyProblemName] = problem.Name
+	newProbEnv.Labels[netconv1alpha1.LabelTemplateHash] = templateHash
+
+	if err := controllerutil.SetControllerReference(problem, &newProbEnv, r.Scheme); err != nil {
+		return "", err
+	}
+
+	if err := r.Create(ctx, &newProbEnv); err != nil {
+		return "", err
+	}
+	return newProbEnv.Name, nil
+}
+
+// computeTemplateHash returns the hash of the template and the data of the ConfigMaps referred by it.
+// The data is included so that fixes of topologies and configs are rolled out as well.
+func (r *ProblemReconciler) computeTemplateHash(
+	ctx context.Context,
+	problem *netconv1alpha1.Problem,
+) (string, error) {
+	hasher := fnv.New32a()
+
+	template, err := json.Marshal(problem.Spec.Template)
+	if err != nil {
+		return "", err
+	}
+	hasher.Write(template)
+
+	sources := []netconv1alpha1.FileSource{}
+	if problem.Spec.Template != nil {
+		sources = append(sources, problem.Spec.Template.Spec.TopologyFile)
+		sources = append(sources, problem.Spec.Template.Spec.ConfigFiles...)
+	}
+
+	for _, source := range sources {
+		configMap := corev1.ConfigMap{}
+		err := r.Get(ctx, types.NamespacedName{
+			Namespace: problem.Namespace,
+			Name:      source.ConfigMapRef.Name,
+		}, &configMap)
+		if err != nil {
+			// missing ConfigMap will be reported when ProblemEnvironment is scheduled
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return "", err
+		}
+
+		hasher.Write([]byte(source.ConfigMapRef.Name))
+		hasher.Write([]byte(source.ConfigMapRef.Key))
+		hasher.Write([]byte(configMap.Data[source.ConfigMapRef.Key]))
+	}
+
+	return rand.SafeEncodeString(fmt.Sprint(hasher.Sum32())), nil
+}
+
+// isOutdated returns true if the ProblemEnvironment was created from the old template.
+// ProblemEnvironments created before the template hash was introduced are
+// considered up-to-date not to redeploy all of them at once.
+func isOutdated(problemEnvironment *netconv1alpha1.ProblemEnvironment, templateHash string) bool {
+	hash, ok := problemEnvironment.Labels[netconv1alpha1.LabelTemplateHash]
+	return ok && hash != templateHash
+}
+
+func isReady(problemEnvironment *netconv1alpha1.ProblemEnvironment) bool {
+	return util.GetProblemEnvironmentCondition(
+		problemEnvironment,
+		netconv1alpha1.ProblemEnvironmentConditionReady,
+	) == metav1.ConditionTrue
+}
+
+func countReady(problemEnvironments []netconv1alpha1.ProblemEnvironment) int {
+	ready := 0
+	for i := range problemEnvironments {
+		if isReady(&problemEnvironments[i]) {
+			ready++
+		}
+	}
+	return ready
+}
+
+// desiredAssignableReplicas returns the number of assignable ProblemEnvironments
+// decided by AssignableReplicas or Autoscaling, and limited by PoolPolicy.
+func desiredAssignableReplicas(problem *netconv1alpha1.Problem) int {
+	assignableReplicas := util.GetAssignableReplicas(problem)
+	if allocated := problem.Status.AllocatedReplicas; allocated != nil {
+		assignableReplicas = min(assignableReplicas, *allocated)
+	}
+	return assignableReplicas
+}
+
+// rolloutParameters returns MaxSurge and MaxUnavailable of RolloutStrategy with defaults applied.
+func rolloutParameters(problem *netconv1alpha1.Problem) (int, int) {
+	maxSurge, maxUnavailable := problem.Spec.RolloutStrategy.MaxSurge, problem.Spec.RolloutStrategy.MaxUnavailable
+	if maxSurge == 0 && maxUnavailable == 0 {
+		maxSurge = 1
+	}
+	return maxSurge, maxUnavailable
+}
+
+// deletionCost returns the cost to delete the ProblemEnvironment. ProblemEnvironments
+// not scheduled yet cost nothing, and ones not ready cost less than ready ones.
+func deletionCost(problemEnvironment *netconv1alpha1.ProblemEnvironment) int {
+	if util.GetProblemEnvironmentCondition(
+		problemEnvironment,
+		netconv1alpha1.ProblemEnvironmentConditionScheduled,
+	) != metav1.ConditionTrue {
+		return 0
+	}
+	if !isReady(problemEnvironment) {
+		return 1
+	}
+	return 2
+}
+
+// sortForDeletion returns ProblemEnvironments sorted in the order to be deleted:
+// ones not scheduled yet, ones not ready, and then the newest.
+func sortForDeletion(problemEnvironments []netconv1alpha1.ProblemEnvironment) []netconv1alpha1.ProblemEnvironment {
+	sorted := append([]netconv1alpha1.ProblemEnvironment{}, problemEnvironments...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		costI, costJ := deletionCost(&sorted[i]), deletionCost(&sorted[j])
+		if costI != costJ {
+			return costI < costJ
+		}
+		return sorted[j].CreationTimestamp.Before(&sorted[i].CreationTimestamp)
+	})
+	return sorted
+}
+
+// updateStatus updates the replica counts and the conditions of the Problem.
+// reconcileErr is the error which stopped the reconciliation, and it's returned as is
+// after updating the status.
+func (r *ProblemReconciler) updateStatus(
+	ctx context.Context,
+	problem *netconv1alpha1.Problem,
+	reconcileErr error,
+) (ctrl.Result, error) {
 	log := log.FromContext(ctx)
 
 	problemEnvironments, err := r.listChildProblemEnvironments(ctx, problem)
 	if err != nil {
 		log.Error(err, "failed to list child ProblemEnvironments")
-		return ctrl.Result{}, nil
+		return ctrl.Result{}, reconcileErr
 	}
 
-	scheduled, assignable, assigned := 0, 0, 0
+	scheduled, assignable, assigned, updated, updatedReady, outdated := 0, 0, 0, 0, 0, 0
 	for _, pe := range problemEnvironments.Items {
 		isScheduled := util.GetProblemEnvironmentCondition(&pe, netconv1alpha1.ProblemEnvironmentConditionScheduled)
 		isReady := util.GetProblemEnvironmentCondition(&pe, netconv1alpha1.ProblemEnvironmentConditionReady)
@@ -167,6 +532,17 @@ func (r *ProblemReconciler) updateStatus(ctx context.Context, problem *netconv1a
 			scheduled++
 		}
 
+		if pe.DeletionTimestamp == nil && isAssigned != metav1.ConditionTrue {
+			if isOutdated(&pe, problem.Status.TemplateHash) {
+				outdated++
+			} else {
+				updated++
+				if isReady == metav1.ConditionTrue {
+					updatedReady++
+				}
+			}
+		}
+
 		if isReady == metav1.ConditionTrue {
 			if isAssigned != metav1.ConditionTrue {
 				assignable++
@@ -180,14 +556,166 @@ func (r *ProblemReconciler) updateStatus(ctx context.Context, problem *netconv1a
 	problem.Status.Replicas.Scheduled = scheduled
 	problem.Status.Replicas.Assignable = assignable
 	problem.Status.Replicas.Assigned = assigned
+	problem.Status.Replicas.Updated = updated
+	problem.Status.Replicas.Outdated = outdated
+
+	desired := desiredAssignableReplicas(problem)
+	_, maxUnavailable := rolloutParameters(problem)
+
+	if minAvailable := desired - maxUnavailable; assignable >= minAvailable {
+		util.SetProblemCondition(problem, netconv1alpha1.ProblemConditionAvailable, metav1.ConditionTrue,
+			"MinimumReplicasAvailable",
+			fmt.Sprintf("%d of %d ProblemEnvironments are assignable", assignable, desired))
+	} else {
+		util.SetProblemCondition(problem, netconv1alpha1.ProblemConditionAvailable, metav1.ConditionFalse,
+			"MinimumReplicasUnavailable",
+			fmt.Sprintf("%d of %d ProblemEnvironments are assignable, at least %d required", assignable, desired, minAvailable))
+	}
+
+	if updated != desired || updatedReady != desired || outdated != 0 {
+		util.SetProblemCondition(problem, netconv1alpha1.ProblemConditionProgressing, metav1.ConditionTrue,
+			"ReplicasUpdating",
+			fmt.Sprintf("%d of %d ProblemEnvironments are updated and ready, %d outdated", updatedReady, desired, outdated))
+	} else {
+		util.SetProblemCondition(problem, netconv1alpha1.ProblemConditionProgressing, metav1.ConditionFalse,
+			"ReplicasUpdated", "All ProblemEnvironments are updated and ready")
+	}
+
+	if reconcileErr != nil {
+		util.SetProblemCondition(problem, netconv1alpha1.ProblemConditionDegraded, metav1.ConditionTrue,
+			"ReconcileError", reconcileErr.Error())
+	} else {
+		util.SetProblemCondition(problem, netconv1alpha1.ProblemConditionDegraded, metav1.ConditionFalse,
+			"ReconcileSucceeded", "")
+	}
+
+	problem.Status.ObservedGeneration = problem.Generation
+
+	if err := r.Status().Update(ctx, problem); err != nil {
+		return ctrl.Result{}, errors.Join(reconcileErr, err)
+	}
+	return ctrl.Result{}, reconcileErr
+}
+
+// ConfigMapCacheSelector selects ConfigMaps cached by controller-manager. The deploy logs recorded
+// by nclet are excluded, as they are created for every attempt to deploy and never referred by Problems.
+func ConfigMapCacheSelector() labels.Selector {
+	requirement, err := labels.NewRequirement(netconv1alpha1.LabelDeployLogFor, selection.DoesNotExist, nil)
+	if err != nil {
+		panic(err)
+	}
+	return labels.NewSelector().Add(*requirement)
+}
+
+// notDeployLog ignores the deploy logs even if they are cached without ConfigMapCacheSelector.
+var notDeployLog = predicate.NewPredicateFuncs(func(obj client.Object) bool {
+	_, ok := obj.GetLabels()[netconv1alpha1.LabelDeployLogFor]
+	return !ok
+})
+
+// problemsReferringConfigMap maps ConfigMap to Problems referring it to roll out changes of ConfigMap.
+func (r *ProblemReconciler) problemsReferringConfigMap(ctx context.Context, obj client.Object) []reconcile.Request {
+	log := log.FromContext(ctx)
+
+	problems := netconv1alpha1.ProblemList{}
+	if err := r.List(ctx, &problems, client.InNamespace(obj.GetNamespace())); err != nil {
+		log.Error(err, "failed to list Problems")
+		return nil
+	}
+
+	requests := []reconcile.Request{}
+	for _, problem := range problems.Items {
+		if problem.Spec.Template == nil {
+			continue
+		}
+
+		spec := &problem.Spec.Template.Spec
+		sources := append([]netconv1alpha1.FileSource{spec.TopologyFile}, spec.ConfigFiles...)
+		for _, source := range sources {
+			if source.ConfigMapRef.Name == obj.GetName() {
+				requests = append(requests, reconcile.Request{
+					NamespacedName: types.NamespacedName{Namespace: problem.Namespace, Name: problem.Name},
+				})
+				break
+			}
+		}
+	}
+	return requests
+}
+
+// problemsInPool maps PoolPolicy and Problem to all Problems sharing the budget of PoolPolicy,
+// as the change of either of them can change the allocation to the others.
+func (r *ProblemReconciler) problemsInPool(ctx context.Context, obj client.Object) []reconcile.Request {
+	log := log.FromContext(ctx)
+
+	if _, ok := obj.(*netconv1alpha1.PoolPolicy); !ok {
+		policy := netconv1alpha1.PoolPolicy{}
+		if err := r.Get(ctx, types.NamespacedName{Name: netconv1alpha1.DefaultPoolPolicyName}, &policy); err != nil {
+			return nil
+		}
+	}
+
+	problems := netconv1alpha1.ProblemList{}
+	if err := r.List(ctx, &problems); err != nil {
+		log.Error(err, "failed to list Problems")
+		return nil
+	}
 
-	return ctrl.Result{}, r.Status().Update(ctx, problem)
+	requests := []reconcile.Request{}
+	for _, problem := range problems.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&problem)})
+	}
+	return requests
+}
+
+// poolDemandChanged fires only when the change of Problem can change the allocation to the others.
+// Other changes, such as the status updated by Reconcile or the acquisitions recorded by gateway,
+// are ignored not to reconcile all Problems in the pool for each of them.
+var poolDemandChanged = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		oldProblem, ok := e.ObjectOld.(*netconv1alpha1.Problem)
+		if !ok {
+			return false
+		}
+		newProblem, ok := e.ObjectNew.(*netconv1alpha1.Problem)
+		if !ok {
+			return false
+		}
+
+		if util.GetAssignableReplicas(oldProblem) != util.GetAssignableReplicas(newProblem) {
+			return true
+		}
+		if (oldProblem.DeletionTimestamp == nil) != (newProblem.DeletionTimestamp == nil) {
+			return true
+		}
+		// the template decides the resource requests of the demand
+		return !equality.Semantic.DeepEqual(oldProblem.Spec.Template, newProblem.Spec.Template)
+	},
+	GenericFunc: func(e event.GenericEvent) bool {
+		return false
+	},
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *ProblemReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.CreationQPS > 0 {
+		r.limiter = rate.NewLimiter(rate.Limit(r.CreationQPS), max(r.CreationBurst, 1))
+	}
+	r.expectations = newCreationExpectations()
+
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&netconv1alpha1.Problem{}).
 		Owns(&netconv1alpha1.ProblemEnvironment{}).
+		Watches(
+			&corev1.ConfigMap{},
+			handler.EnqueueRequestsFromMapFunc(r.problemsReferringConfigMap),
+			builder.WithPredicates(notDeployLog),
+		).
+		Watches(&netconv1alpha1.PoolPolicy{}, handler.EnqueueRequestsFromMapFunc(r.problemsInPool)).
+		Watches(
+			&netconv1alpha1.Problem{},
+			handler.EnqueueRequestsFromMapFunc(r.problemsInPool),
+			builder.WithPredicates(poolDemandChanged),
+		).
 		Complete(r)
 }
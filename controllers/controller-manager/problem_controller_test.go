@@ -10,6 +10,7 @@ import (
 	util "github.com/janog-netcon/netcon-problem-management-subsystem/pkg/util"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
@@ -41,8 +42,10 @@ var _ = Describe("Problem controller", func() {
 		Expect(err).ToNot(HaveOccurred())
 
 		err = (&ProblemReconciler{
-			Client: k8sClient,
-			Scheme: scheme.Scheme,
+			Client:        k8sClient,
+			Scheme:        scheme.Scheme,
+			Recorder:      mgr.GetEventRecorderFor("problem-controller"),
+			CreationBurst: 5,
 		}).SetupWithManager(mgr)
 		Expect(err).NotTo(HaveOccurred())
 
@@ -214,4 +217,362 @@ var _ = Describe("Problem controller", func() {
 		checkReplicas(1, 3).Should(Succeed())
 		time.Sleep(100 * time.Millisecond)
 	})
+
+	It("should replace outdated ProblemEnvironments when ConfigMap is updated", func() {
+		configMap := corev1.ConfigMap{}
+		configMap.Namespace = "default"
+		configMap.Name = "tst-003"
+		configMap.Data = map[string]string{"manifest.yml": "name: tst-003"}
+		err := k8sClient.Create(ctx, &configMap)
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(func() {
+			Expect(k8sClient.Delete(ctx, &configMap)).To(Succeed())
+		})
+
+		problem := netconv1alpha1.Problem{}
+		err = loadManifest(filepath.Join("tests", "problems", "problem-tst-003.yaml"), &problem)
+		Expect(err).NotTo(HaveOccurred())
+
+		err = k8sClient.Create(ctx, &problem)
+		Expect(err).NotTo(HaveOccurred())
+
+		problemEnvironments := netconv1alpha1.ProblemEnvironmentList{}
+
+		checkTemplateHash := func() AsyncAssertion {
+			return Eventually(func() error {
+				if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(&problem), &problem); err != nil {
+					return err
+				}
+				if problem.Status.TemplateHash == "" {
+					return fmt.Errorf("templateHash is not set")
+				}
+
+				if err := k8sClient.List(ctx, &problemEnvironments); err != nil {
+					return err
+				}
+
+				updated := 0
+				for _, problemEnvironment := range problemEnvironments.Items {
+					if problemEnvironment.DeletionTimestamp != nil {
+						continue
+					}
+					hash := problemEnvironment.Labels[netconv1alpha1.LabelTemplateHash]
+					if hash != problem.Status.TemplateHash {
+						return fmt.Errorf("%s is outdated", problemEnvironment.Name)
+					}
+					updated++
+				}
+
+				if updated != problem.Spec.AssignableReplicas {
+					return fmt.Errorf("got %d updated items", updated)
+				}
+				return nil
+			})
+		}
+
+		checkTemplateHash().Should(Succeed())
+		oldTemplateHash := problem.Status.TemplateHash
+
+		// mark one of ProblemEnvironments as assigned, which must not be replaced
+		assigned := problemEnvironments.Items[0]
+		util.SetProblemEnvironmentCondition(
+			&assigned,
+			netconv1alpha1.ProblemEnvironmentConditionAssigned,
+			metav1.ConditionTrue,
+			"TEST", "---")
+		err = k8sClient.Status().Update(ctx, &assigned)
+		Expect(err).NotTo(HaveOccurred())
+		time.Sleep(100 * time.Millisecond)
+
+		configMap.Data = map[string]string{"manifest.yml": "name: tst-003-fixed"}
+		err = k8sClient.Update(ctx, &configMap)
+		Expect(err).NotTo(HaveOccurred())
+
+		Eventually(func() error {
+			if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(&problem), &problem); err != nil {
+				return err
+			}
+			if problem.Status.TemplateHash == oldTemplateHash {
+				return fmt.Errorf("templateHash is not updated")
+			}
+			if problem.Status.Replicas.Updated != 2 || problem.Status.Replicas.Outdated != 0 {
+				return fmt.Errorf("%d updated, %d outdated", problem.Status.Replicas.Updated, problem.Status.Replicas.Outdated)
+			}
+			return nil
+		}).Should(Succeed())
+
+		err = k8sClient.Get(ctx, client.ObjectKeyFromObject(&assigned), &assigned)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(assigned.DeletionTimestamp).To(BeNil())
+		Expect(assigned.Labels[netconv1alpha1.LabelTemplateHash]).To(Equal(oldTemplateHash))
+	})
+
+	It("should scale assignableReplicas with acquisitions when autoscaling is enabled", func() {
+		problem := netconv1alpha1.Problem{}
+		err := loadManifest(filepath.Join("tests", "problems", "problem-tst-004.yaml"), &problem)
+		Expect(err).NotTo(HaveOccurred())
+
+		err = k8sClient.Create(ctx, &problem)
+		Expect(err).NotTo(HaveOccurred())
+
+		checkReplicas := func(replicas int) AsyncAssertion {
+			return Eventually(func() error {
+				problemEnvironments := netconv1alpha1.ProblemEnvironmentList{}
+				if err := k8sClient.List(ctx, &problemEnvironments); err != nil {
+					return err
+				}
+
+				if len(problemEnvironments.Items) != replicas {
+					return fmt.Errorf("got %d items", len(problemEnvironments.Items))
+				}
+				return nil
+			})
+		}
+
+		// without acquisitions, only targetFreeReplicas are kept
+		checkReplicas(2).Should(Succeed())
+
+		// 2 acquisitions are recorded by gateway
+		Eventually(func() error {
+			if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(&problem), &problem); err != nil {
+				return err
+			}
+			util.RecordProblemAcquisition(&problem, time.Now())
+			util.RecordProblemAcquisition(&problem, time.Now())
+			return k8sClient.Status().Update(ctx, &problem)
+		}).Should(Succeed())
+
+		checkReplicas(4).Should(Succeed())
+
+		// 3 more acquisitions are recorded, but it's capped by maxReplicas
+		Eventually(func() error {
+			if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(&problem), &problem); err != nil {
+				return err
+			}
+			for range 3 {
+				util.RecordProblemAcquisition(&problem, time.Now())
+			}
+			return k8sClient.Status().Update(ctx, &problem)
+		}).Should(Succeed())
+
+		checkReplicas(5).Should(Succeed())
+	})
+
+	It("should split the budget of PoolPolicy among Problems", func() {
+		maxReplicas := 3
+		policy := netconv1alpha1.PoolPolicy{}
+		policy.Name = netconv1alpha1.DefaultPoolPolicyName
+		policy.Spec = netconv1alpha1.PoolPolicySpec{
+			MaxReplicas: &maxReplicas,
+			Problems: []netconv1alpha1.ProblemPoolPolicy{
+				{Namespace: "default", Name: "tst-005", Priority: 10},
+				{Namespace: "default", Name: "tst-006", MinReplicas: 1},
+			},
+		}
+		err := k8sClient.Create(ctx, &policy)
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(func() {
+			Expect(k8sClient.Delete(ctx, &policy)).To(Succeed())
+		})
+
+		for _, name := range []string{"problem-tst-005.yaml", "problem-tst-006.yaml"} {
+			problem := netconv1alpha1.Problem{}
+			err := loadManifest(filepath.Join("tests", "problems", name), &problem)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = k8sClient.Create(ctx, &problem)
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		checkReplicas := func(expected map[string]int) AsyncAssertion {
+			return Eventually(func() error {
+				problemEnvironments := netconv1alpha1.ProblemEnvironmentList{}
+				if err := k8sClient.List(ctx, &problemEnvironments); err != nil {
+					return err
+				}
+
+				actual := map[string]int{}
+				for _, problemEnvironment := range problemEnvironments.Items {
+					if problemEnvironment.DeletionTimestamp == nil {
+						actual[problemEnvironment.Labels[netconv1alpha1.LabelProblemName]]++
+					}
+				}
+
+				for name, replicas := range expected {
+					if actual[name] != replicas {
+						return fmt.Errorf("got %d items for %s", actual[name], name)
+					}
+				}
+				return nil
+			})
+		}
+
+		// tst-006 gets its minimum, and tst-005 with higher priority gets the rest
+		checkReplicas(map[string]int{"tst-005": 2, "tst-006": 1}).Should(Succeed())
+
+		// once the budget is expanded, both of them meet assignableReplicas
+		Eventually(func() error {
+			if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(&policy), &policy); err != nil {
+				return err
+			}
+			*policy.Spec.MaxReplicas = 6
+			return k8sClient.Update(ctx, &policy)
+		}).Should(Succeed())
+
+		checkReplicas(map[string]int{"tst-005": 3, "tst-006": 3}).Should(Succeed())
+	})
+
+	It("should delete ProblemEnvironments not scheduled first when assignableReplicas is decreased", func() {
+		problem := netconv1alpha1.Problem{}
+		err := loadManifest(filepath.Join("tests", "problems", "problem-tst-007.yaml"), &problem)
+		Expect(err).NotTo(HaveOccurred())
+
+		err = k8sClient.Create(ctx, &problem)
+		Expect(err).NotTo(HaveOccurred())
+
+		problemEnvironments := netconv1alpha1.ProblemEnvironmentList{}
+		Eventually(func() error {
+			if err := k8sClient.List(ctx, &problemEnvironments); err != nil {
+				return err
+			}
+			if len(problemEnvironments.Items) != 3 {
+				return fmt.Errorf("got %d items", len(problemEnvironments.Items))
+			}
+			return nil
+		}).Should(Succeed())
+
+		// all but the first one are scheduled and ready
+		kept := map[string]bool{}
+		for _, problemEnvironment := range problemEnvironments.Items[1:] {
+			util.SetProblemEnvironmentCondition(
+				&problemEnvironment,
+				netconv1alpha1.ProblemEnvironmentConditionScheduled,
+				metav1.ConditionTrue,
+				"TEST", "---")
+			util.SetProblemEnvironmentCondition(
+				&problemEnvironment,
+				netconv1alpha1.ProblemEnvironmentConditionReady,
+				metav1.ConditionTrue,
+				"TEST", "---")
+			err = k8sClient.Status().Update(ctx, &problemEnvironment)
+			Expect(err).NotTo(HaveOccurred())
+			kept[problemEnvironment.Name] = true
+		}
+		time.Sleep(100 * time.Millisecond)
+
+		Eventually(func() error {
+			if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(&problem), &problem); err != nil {
+				return err
+			}
+			problem.Spec.AssignableReplicas = 2
+			return k8sClient.Update(ctx, &problem)
+		}).Should(Succeed())
+
+		Eventually(func() error {
+			if err := k8sClient.List(ctx, &problemEnvironments); err != nil {
+				return err
+			}
+			if len(problemEnvironments.Items) != 2 {
+				return fmt.Errorf("got %d items", len(problemEnvironments.Items))
+			}
+			for _, problemEnvironment := range problemEnvironments.Items {
+				if !kept[problemEnvironment.Name] {
+					return fmt.Errorf("%s should be deleted", problemEnvironment.Name)
+				}
+			}
+			return nil
+		}).Should(Succeed())
+	})
+
+	It("should report conditions of Problem", func() {
+		problem := netconv1alpha1.Problem{}
+		err := loadManifest(filepath.Join("tests", "problems", "problem-tst-008.yaml"), &problem)
+		Expect(err).NotTo(HaveOccurred())
+
+		err = k8sClient.Create(ctx, &problem)
+		Expect(err).NotTo(HaveOccurred())
+
+		checkConditions := func(available, progressing metav1.ConditionStatus) AsyncAssertion {
+			return Eventually(func() error {
+				if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(&problem), &problem); err != nil {
+					return err
+				}
+				if problem.Status.ObservedGeneration != problem.Generation {
+					return fmt.Errorf("generation %d is not observed yet", problem.Generation)
+				}
+
+				actualAvailable := util.GetProblemCondition(&problem, netconv1alpha1.ProblemConditionAvailable)
+				actualProgressing := util.GetProblemCondition(&problem, netconv1alpha1.ProblemConditionProgressing)
+				if actualAvailable != available || actualProgressing != progressing {
+					return fmt.Errorf("Available=%s, Progressing=%s", actualAvailable, actualProgressing)
+				}
+				if util.GetProblemCondition(&problem, netconv1alpha1.ProblemConditionDegraded) != metav1.ConditionFalse {
+					return fmt.Errorf("Degraded is not False")
+				}
+				return nil
+			})
+		}
+
+		// ProblemEnvironment is created, but not ready yet
+		checkConditions(metav1.ConditionFalse, metav1.ConditionTrue).Should(Succeed())
+
+		problemEnvironments := netconv1alpha1.ProblemEnvironmentList{}
+		err = k8sClient.List(ctx, &problemEnvironments)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(problemEnvironments.Items).To(HaveLen(1))
+
+		problemEnvironment := problemEnvironments.Items[0]
+		util.SetProblemEnvironmentCondition(
+			&problemEnvironment,
+			netconv1alpha1.ProblemEnvironmentConditionReady,
+			metav1.ConditionTrue,
+			"TEST", "---")
+		err = k8sClient.Status().Update(ctx, &problemEnvironment)
+		Expect(err).NotTo(HaveOccurred())
+
+		checkConditions(metav1.ConditionTrue, metav1.ConditionFalse).Should(Succeed())
+	})
+
+	It("should replace ProblemEnvironments failed to deploy", func() {
+		problem := netconv1alpha1.Problem{}
+		err := loadManifest(filepath.Join("tests", "problems", "problem-tst-009.yaml"), &problem)
+		Expect(err).NotTo(HaveOccurred())
+
+		err = k8sClient.Create(ctx, &problem)
+		Expect(err).NotTo(HaveOccurred())
+
+		problemEnvironments := netconv1alpha1.ProblemEnvironmentList{}
+		Eventually(func() error {
+			if err := k8sClient.List(ctx, &problemEnvironments); err != nil {
+				return err
+			}
+			if len(problemEnvironments.Items) != 1 {
+				return fmt.Errorf("%d ProblemEnvironments exist", len(problemEnvironments.Items))
+			}
+			return nil
+		}).Should(Succeed())
+
+		failed := problemEnvironments.Items[0]
+		util.SetProblemEnvironmentCondition(
+			&failed,
+			netconv1alpha1.ProblemEnvironmentConditionFailed,
+			metav1.ConditionTrue,
+			"TEST", "---")
+		err = k8sClient.Status().Update(ctx, &failed)
+		Expect(err).NotTo(HaveOccurred())
+
+		// the failed one is deleted, and new one is created instead
+		Eventually(func() error {
+			if err := k8sClient.List(ctx, &problemEnvironments); err != nil {
+				return err
+			}
+			if len(problemEnvironments.Items) != 1 {
+				return fmt.Errorf("%d ProblemEnvironments exist", len(problemEnvironments.Items))
+			}
+			if problemEnvironments.Items[0].Name == failed.Name {
+				return fmt.Errorf("failed ProblemEnvironment %s is not replaced", failed.Name)
+			}
+			return nil
+		}).Should(Succeed())
+	})
 })
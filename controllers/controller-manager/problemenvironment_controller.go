@@ -19,14 +19,11 @@ package controllers
 import (
 	"context"
 	"fmt"
-	"math"
-	"math/rand/v2"
-	"strconv"
 	"time"
 
+	"gopkg.in/yaml.v3"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
@@ -35,7 +32,9 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	netconv1alpha1 "github.com/janog-netcon/netcon-problem-management-subsystem/api/v1alpha1"
+	"github.com/janog-netcon/netcon-problem-management-subsystem/pkg/containerlab"
 	"github.com/janog-netcon/netcon-problem-management-subsystem/pkg/crypto"
+	"github.com/janog-netcon/netcon-problem-management-subsystem/pkg/scheduler"
 	"github.com/janog-netcon/netcon-problem-management-subsystem/pkg/util"
 )
 
@@ -46,6 +45,14 @@ type ProblemEnvironmentReconciler struct {
 	Recorder record.EventRecorder
 
 	Parameters SchedulerParameters
+
+	// Scheduler elects Worker for ProblemEnvironment.
+	// If Scheduler is nil, the default one is built from Parameters.
+	Scheduler *scheduler.Framework
+
+	// RandomSource is used by the default Scheduler to elect Worker.
+	// If RandomSource is nil, the global source is used.
+	RandomSource scheduler.RandomSource
 }
 
 type SchedulerParameters struct {
@@ -53,15 +60,17 @@ type SchedulerParameters struct {
 	MemoryWeight    float64
 	MemoryThreshold float64
 	Temperature     float64
+
+	MaxPendingDeployments int
 }
 
-const MAX_USED_PERCENT float64 = 100.0
 const DEFAULT_PASSWORD_LENGTH = 24
 
 //+kubebuilder:rbac:groups=netcon.janog.gr.jp,resources=problemenvironments,verbs=get;list;watch;update;patch
 //+kubebuilder:rbac:groups=netcon.janog.gr.jp,resources=problemenvironments/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=netcon.janog.gr.jp,resources=problems,verbs=get;list;watch
 //+kubebuilder:rbac:groups=netcon.janog.gr.jp,resources=workers,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -126,113 +135,42 @@ func (r *ProblemEnvironmentReconciler) updateStatus(
 	return res, nil
 }
 
-type CandidateWorker struct {
-	// Name is the name of the worker.
-	Name string
-
-	// Score is the score of the worker (0..1).
-	Score float64
-}
-
-func (r *ProblemEnvironmentReconciler) electWorker(
+// getTopology loads the topology file of the ProblemEnvironment.
+func (r *ProblemEnvironmentReconciler) getTopology(
 	ctx context.Context,
-	workers netconv1alpha1.WorkerList,
-	workerSelectors []metav1.LabelSelector,
-) string {
-	log := log.FromContext(ctx)
-
-	workerLength := len(workers.Items)
-
-	candidates := make([]CandidateWorker, 0)
-	for i := 0; i < workerLength; i++ {
-		if util.GetWorkerCondition(
-			&workers.Items[i],
-			netconv1alpha1.WorkerConditionReady,
-		) != metav1.ConditionTrue {
-			continue
-		}
-
-		if workers.Items[i].Spec.DisableSchedule {
-			continue
-		}
-
-		// check if worker matches workerSelectors
-		if len(workerSelectors) > 0 {
-			matched := false
-			for _, selector := range workerSelectors {
-				s, err := metav1.LabelSelectorAsSelector(&selector)
-				if err != nil {
-					log.Error(err, "failed to parse label selector")
-					continue
-				}
-				if s.Matches(labels.Set(workers.Items[i].Labels)) {
-					matched = true
-					break
-				}
-			}
-			if !matched {
-				continue
-			}
-		}
-
-		cpuUsedPercent, err := strconv.ParseFloat(workers.Items[i].Status.WorkerInfo.CPUUsedPercent, 64)
-		if err != nil {
-			log.Error(err, "failed to parse CPUUsedPercent for worker election")
-			cpuUsedPercent = MAX_USED_PERCENT
-		}
-
-		memoryUsedPercent, err := strconv.ParseFloat(workers.Items[i].Status.WorkerInfo.MemoryUsedPercent, 64)
-		if err != nil {
-			log.Error(err, "failed to parse MemoryUsedPercent for worker election")
-			memoryUsedPercent = MAX_USED_PERCENT
-		}
-
-		// If memory usage is above threshold, it's too danger to deploy to this worker.
-		if memoryUsedPercent > r.Parameters.MemoryThreshold {
-			continue
-		}
-
-		// Note: Score is scaled from 0 to 1
-		cost := (cpuUsedPercent*r.Parameters.CPUWeight + memoryUsedPercent*r.Parameters.MemoryWeight)
-		cost = cost / (r.Parameters.CPUWeight + r.Parameters.MemoryWeight) / 100
-		score := 1 - cost
-
-		candidates = append(candidates, CandidateWorker{
-			Name:  workers.Items[i].Name,
-			Score: score,
-		})
-	}
-	if len(candidates) == 0 {
-		return ""
-	}
-
-	return r.electWorkerFromCandidates(candidates)
+	problemEnvironment *netconv1alpha1.ProblemEnvironment,
+) (*containerlab.Topology, error) {
+	return loadTopology(ctx, r.Client, problemEnvironment.Namespace, problemEnvironment.Spec.TopologyFile)
 }
 
-// electWorkerFromCandidates elects a worker from candidates based on the score.
-// It uses the Boltzmann distribution to select a worker.
-func (r *ProblemEnvironmentReconciler) electWorkerFromCandidates(candidates []CandidateWorker) string {
-	totalCandidates := len(candidates)
-
-	tmps := make([]float64, totalCandidates)
-	for i := range totalCandidates {
-		tmps[i] = math.Exp(candidates[i].Score / r.Parameters.Temperature)
+// loadTopology loads the topology from the ConfigMap referred by source.
+func loadTopology(
+	ctx context.Context,
+	c client.Client,
+	namespace string,
+	source netconv1alpha1.FileSource,
+) (*containerlab.Topology, error) {
+	configMapRef := source.ConfigMapRef
+
+	configMap := corev1.ConfigMap{}
+	if err := c.Get(ctx, types.NamespacedName{
+		Namespace: namespace,
+		Name:      configMapRef.Name,
+	}, &configMap); err != nil {
+		return nil, err
 	}
 
-	total := 0.0
-	for i := range totalCandidates {
-		total += tmps[i]
+	data, ok := configMap.Data[configMapRef.Key]
+	if !ok {
+		return nil, fmt.Errorf("ConfigMap found, but key `%s` missing", configMapRef.Key)
 	}
 
-	v := rand.Float64()
-	for i := range totalCandidates {
-		v -= tmps[i] / total
-		if v < 0 {
-			return candidates[i].Name
-		}
+	config := containerlab.Config{}
+	if err := yaml.Unmarshal([]byte(data), &config); err != nil {
+		return nil, err
 	}
 
-	return candidates[totalCandidates-1].Name
+	return &config.Topology, nil
 }
 
 func (r *ProblemEnvironmentReconciler) schedule(
@@ -241,6 +179,23 @@ func (r *ProblemEnvironmentReconciler) schedule(
 ) (ctrl.Result, error) {
 	log := log.FromContext(ctx)
 
+	topology, err := r.getTopology(ctx, problemEnvironment)
+	if err != nil {
+		log.Error(err, "failed to load topology, scheduling without it")
+	}
+
+	// ResourceRequests is persisted before scheduling so that the following
+	// scheduling cycles can count it for the Worker where it's scheduled.
+	if problemEnvironment.Status.ResourceRequests == nil && topology != nil {
+		resourceRequests, err := topology.ResourceRequests()
+		if err != nil {
+			log.Error(err, "failed to calculate resource requests, scheduling without them")
+		} else {
+			problemEnvironment.Status.ResourceRequests = resourceRequests
+			return r.updateStatus(ctx, problemEnvironment, ctrl.Result{Requeue: true})
+		}
+	}
+
 	log.V(1).Info("fetching Worker list for scheduling")
 	workers := netconv1alpha1.WorkerList{}
 	if err := r.List(ctx, &workers); err != nil {
@@ -256,7 +211,27 @@ func (r *ProblemEnvironmentReconciler) schedule(
 		return r.updateStatus(ctx, problemEnvironment, ctrl.Result{RequeueAfter: 3 * time.Second})
 	}
 
-	electedWorkerName := r.electWorker(ctx, workers, problemEnvironment.Spec.WorkerSelectors)
+	log.V(1).Info("fetching ProblemEnvironment list for scheduling")
+	problemEnvironments := netconv1alpha1.ProblemEnvironmentList{}
+	if err := r.List(ctx, &problemEnvironments); err != nil {
+		log.Error(err, "failed to list ProblemEnvironments")
+		return ctrl.Result{}, err
+	}
+
+	log.V(1).Info("fetching Problem list for scheduling")
+	problems := netconv1alpha1.ProblemList{}
+	if err := r.List(ctx, &problems, client.InNamespace(problemEnvironment.Namespace)); err != nil {
+		log.Error(err, "failed to list Problems")
+		return ctrl.Result{}, err
+	}
+
+	state := scheduler.CycleState{
+		Workers:             workers.Items,
+		ProblemEnvironments: problemEnvironments.Items,
+		Problems:            problems.Items,
+		Topology:            topology,
+	}
+	electedWorkerName, diagnosis := r.Scheduler.Schedule(ctx, &state, problemEnvironment)
 
 	if electedWorkerName != "" {
 		problemEnvironment.Spec.WorkerName = electedWorkerName
@@ -264,7 +239,7 @@ func (r *ProblemEnvironmentReconciler) schedule(
 	} else {
 		reason := "ScheduleFailed"
 		message := "failed to elect worker for scheduling"
-		log.Info(message)
+		log.Info(message, "diagnosis", diagnosis.String())
 		util.SetProblemEnvironmentCondition(
 			problemEnvironment,
 			netconv1alpha1.ProblemEnvironmentConditionScheduled,
@@ -421,6 +396,14 @@ func (r *ProblemEnvironmentReconciler) checkStatus(
 
 	log.V(1).Info("checking status")
 
+	// Container statuses can't be trusted as nclet on the Worker is not working
+	if util.GetProblemEnvironmentCondition(
+		problemEnvironment,
+		netconv1alpha1.ProblemEnvironmentConditionWorkerLost,
+	) == metav1.ConditionTrue {
+		return ctrl.Result{}, nil
+	}
+
 	if problemEnvironment.Status.Containers == nil {
 		return r.markNotReadyInit(ctx, problemEnvironment, ctrl.Result{})
 	}
@@ -440,6 +423,30 @@ func (r *ProblemEnvironmentReconciler) checkStatus(
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *ProblemEnvironmentReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.Scheduler == nil {
+		opts := []scheduler.Option{}
+		if r.RandomSource != nil {
+			opts = append(opts, scheduler.WithRandomSource(r.RandomSource))
+		}
+
+		framework, err := scheduler.NewFramework(
+			scheduler.NewDefaultConfig(scheduler.DefaultParameters{
+				CPUWeight:       r.Parameters.CPUWeight,
+				MemoryWeight:    r.Parameters.MemoryWeight,
+				MemoryThreshold: r.Parameters.MemoryThreshold,
+				Temperature:     r.Parameters.Temperature,
+
+				MaxPendingDeployments: r.Parameters.MaxPendingDeployments,
+			}),
+			scheduler.NewInTreeRegistry(),
+			opts...,
+		)
+		if err != nil {
+			return err
+		}
+		r.Scheduler = framework
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&netconv1alpha1.ProblemEnvironment{}).
 		Complete(r)
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	netconv1alpha1 "github.com/janog-netcon/netcon-problem-management-subsystem/api/v1alpha1"
+	"github.com/janog-netcon/netcon-problem-management-subsystem/pkg/scheduler"
 	util "github.com/janog-netcon/netcon-problem-management-subsystem/pkg/util"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -49,7 +50,8 @@ var _ = Describe("ProblemEnvironment controller", func() {
 				MemoryThreshold: 90.0,
 				Temperature:     0.1,
 			},
-			Recorder: mgr.GetEventRecorderFor("problemenvironment-controller"),
+			Recorder:     mgr.GetEventRecorderFor("problemenvironment-controller"),
+			RandomSource: scheduler.NewSeededRandomSource(1),
 		}).SetupWithManager(mgr)
 		Expect(err).NotTo(HaveOccurred())
 
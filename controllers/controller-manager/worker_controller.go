@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	netconv1alpha1 "github.com/janog-netcon/netcon-problem-management-subsystem/api/v1alpha1"
@@ -13,6 +14,7 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 )
@@ -22,6 +24,10 @@ type WorkerController struct {
 	recorder record.EventRecorder
 
 	workerMonitorPeriod time.Duration
+
+	// evictionGracePeriod is the period to wait before evicting ProblemEnvironments
+	// from NotReady Workers. 0 disables eviction.
+	evictionGracePeriod time.Duration
 }
 
 var _ manager.Runnable = &WorkerController{}
@@ -29,11 +35,13 @@ var _ manager.Runnable = &WorkerController{}
 func NewWorkerController(
 	client client.Client,
 	workerMonitorPeriod time.Duration,
+	evictionGracePeriod time.Duration,
 	recorder record.EventRecorder,
 ) *WorkerController {
 	return &WorkerController{
 		Client:              client,
 		workerMonitorPeriod: workerMonitorPeriod,
+		evictionGracePeriod: evictionGracePeriod,
 		recorder:            recorder,
 	}
 }
@@ -41,6 +49,8 @@ func NewWorkerController(
 //+kubebuilder:rbac:groups=netcon.janog.gr.jp,resources=workers,verbs=get;list;watch
 //+kubebuilder:rbac:groups=netcon.janog.gr.jp,resources=workers/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=coordination.k8s.io,resources=leases,verbs=get;list;watch
+//+kubebuilder:rbac:groups=netcon.janog.gr.jp,resources=problemenvironments,verbs=get;list;watch;update;patch;delete
+//+kubebuilder:rbac:groups=netcon.janog.gr.jp,resources=problemenvironments/status,verbs=get;update;patch
 
 // Start implements manager.Runnable
 func (wc *WorkerController) Start(ctx context.Context) error {
@@ -128,7 +138,15 @@ func (wc *WorkerController) monitorWorkerHealth(ctx context.Context) error {
 				"HealthCheck",
 				"checked health",
 			)
+			if err := wc.recoverProblemEnvironments(ctx, &worker); err != nil {
+				errList = append(errList, err)
+			}
 		} else {
+			if !ready {
+				if err := wc.evictProblemEnvironments(ctx, &worker); err != nil {
+					errList = append(errList, err)
+				}
+			}
 			continue
 		}
 
@@ -139,3 +157,181 @@ func (wc *WorkerController) monitorWorkerHealth(ctx context.Context) error {
 
 	return multierr.Combine(errList...)
 }
+
+func (wc *WorkerController) listProblemEnvironmentsOn(
+	ctx context.Context,
+	worker *netconv1alpha1.Worker,
+) ([]netconv1alpha1.ProblemEnvironment, error) {
+	problemEnvironments := netconv1alpha1.ProblemEnvironmentList{}
+	if err := wc.List(ctx, &problemEnvironments); err != nil {
+		return nil, err
+	}
+
+	items := []netconv1alpha1.ProblemEnvironment{}
+	for _, problemEnvironment := range problemEnvironments.Items {
+		if problemEnvironment.Spec.WorkerName == worker.Name {
+			items = append(items, problemEnvironment)
+		}
+	}
+	return items, nil
+}
+
+// evictProblemEnvironments evicts ProblemEnvironments from the Worker which has
+// been NotReady longer than evictionGracePeriod.
+//
+// As nclet on the Worker can't remove the finalizer, ProblemEnvironments not assigned
+// or being deleted are force-finalized, and then Problem controller recreates them
+// on other Workers. ProblemEnvironments assigned are kept not to take them away from
+// users, but marked as WorkerLost for operators.
+func (wc *WorkerController) evictProblemEnvironments(ctx context.Context, worker *netconv1alpha1.Worker) error {
+	if wc.evictionGracePeriod == 0 {
+		return nil
+	}
+
+	condition := util.FindWorkerCondition(worker, netconv1alpha1.WorkerConditionReady)
+	if condition == nil || condition.Status == metav1.ConditionTrue {
+		return nil
+	}
+	if time.Since(condition.LastTransitionTime.Time) < wc.evictionGracePeriod {
+		return nil
+	}
+
+	problemEnvironments, err := wc.listProblemEnvironmentsOn(ctx, worker)
+	if err != nil {
+		return err
+	}
+
+	errList := []error{}
+	for i := range problemEnvironments {
+		problemEnvironment := &problemEnvironments[i]
+
+		assigned := util.GetProblemEnvironmentCondition(
+			problemEnvironment,
+			netconv1alpha1.ProblemEnvironmentConditionAssigned,
+		) == metav1.ConditionTrue
+
+		if assigned && problemEnvironment.DeletionTimestamp == nil {
+			if err := wc.markWorkerLost(ctx, worker, condition, problemEnvironment); err != nil {
+				errList = append(errList, err)
+			}
+			continue
+		}
+
+		if err := wc.evict(ctx, worker, problemEnvironment); err != nil {
+			errList = append(errList, err)
+		}
+	}
+
+	return multierr.Combine(errList...)
+}
+
+func (wc *WorkerController) evict(
+	ctx context.Context,
+	worker *netconv1alpha1.Worker,
+	problemEnvironment *netconv1alpha1.ProblemEnvironment,
+) error {
+	log := log.FromContext(ctx)
+
+	log.Info("evicting ProblemEnvironment from NotReady Worker",
+		"worker", worker.Name,
+		"namespace", problemEnvironment.Namespace,
+		"name", problemEnvironment.Name,
+	)
+
+	if controllerutil.RemoveFinalizer(problemEnvironment, netconv1alpha1.ProblemEnvironmentFinalizer) {
+		if err := wc.Update(ctx, problemEnvironment); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+	}
+
+	if problemEnvironment.DeletionTimestamp == nil {
+		if err := wc.Delete(ctx, problemEnvironment); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+	}
+
+	wc.recorder.Eventf(
+		worker,
+		corev1.EventTypeWarning,
+		netconv1alpha1.WorkerEventEvicted,
+		"Evicted ProblemEnvironment %s/%s",
+		problemEnvironment.Namespace,
+		problemEnvironment.Name,
+	)
+	return nil
+}
+
+func (wc *WorkerController) markWorkerLost(
+	ctx context.Context,
+	worker *netconv1alpha1.Worker,
+	readyCondition *metav1.Condition,
+	problemEnvironment *netconv1alpha1.ProblemEnvironment,
+) error {
+	// Suppress unneeded status updates
+	if util.GetProblemEnvironmentCondition(
+		problemEnvironment,
+		netconv1alpha1.ProblemEnvironmentConditionWorkerLost,
+	) == metav1.ConditionTrue {
+		return nil
+	}
+
+	message := fmt.Sprintf(
+		"Worker %s has been NotReady since %s",
+		worker.Name,
+		readyCondition.LastTransitionTime.Format(time.RFC3339),
+	)
+
+	wc.recorder.Event(
+		problemEnvironment,
+		corev1.EventTypeWarning,
+		netconv1alpha1.ProblemEnvironmentEventWorkerLost,
+		message,
+	)
+
+	util.SetProblemEnvironmentCondition(
+		problemEnvironment,
+		netconv1alpha1.ProblemEnvironmentConditionWorkerLost,
+		metav1.ConditionTrue,
+		"WorkerNotReady", message,
+	)
+	util.SetProblemEnvironmentCondition(
+		problemEnvironment,
+		netconv1alpha1.ProblemEnvironmentConditionReady,
+		metav1.ConditionFalse,
+		"WorkerLost", message,
+	)
+	return wc.Status().Update(ctx, problemEnvironment)
+}
+
+// recoverProblemEnvironments clears WorkerLost condition of ProblemEnvironments
+// on the Worker which became Ready again.
+func (wc *WorkerController) recoverProblemEnvironments(ctx context.Context, worker *netconv1alpha1.Worker) error {
+	problemEnvironments, err := wc.listProblemEnvironmentsOn(ctx, worker)
+	if err != nil {
+		return err
+	}
+
+	errList := []error{}
+	for i := range problemEnvironments {
+		problemEnvironment := &problemEnvironments[i]
+
+		if util.GetProblemEnvironmentCondition(
+			problemEnvironment,
+			netconv1alpha1.ProblemEnvironmentConditionWorkerLost,
+		) != metav1.ConditionTrue {
+			continue
+		}
+
+		util.SetProblemEnvironmentCondition(
+			problemEnvironment,
+			netconv1alpha1.ProblemEnvironmentConditionWorkerLost,
+			metav1.ConditionFalse,
+			"WorkerRecovered", "Worker is ready again",
+		)
+		if err := wc.Status().Update(ctx, problemEnvironment); err != nil {
+			errList = append(errList, err)
+		}
+	}
+
+	return multierr.Combine(errList...)
+}
@@ -0,0 +1,128 @@
+package controllers
+
+import (
+	"context"
+	"path/filepath"
+	"time"
+
+	netconv1alpha1 "github.com/janog-netcon/netcon-problem-management-subsystem/api/v1alpha1"
+	util "github.com/janog-netcon/netcon-problem-management-subsystem/pkg/util"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+var _ = Describe("Worker controller", func() {
+	ctx := context.Background()
+
+	BeforeEach(func() {
+		err := k8sClient.DeleteAllOf(ctx, &netconv1alpha1.Worker{})
+		Expect(err).ToNot(HaveOccurred())
+		err = k8sClient.DeleteAllOf(ctx, &netconv1alpha1.ProblemEnvironment{}, client.InNamespace("default"))
+		Expect(err).ToNot(HaveOccurred())
+		time.Sleep(100 * time.Millisecond)
+	})
+
+	createProblemEnvironment := func(manifest string, assigned bool) netconv1alpha1.ProblemEnvironment {
+		problemEnvironment := netconv1alpha1.ProblemEnvironment{}
+		err := loadManifest(filepath.Join("tests", "problemenvironments", manifest), &problemEnvironment)
+		Expect(err).NotTo(HaveOccurred())
+
+		problemEnvironment.Spec.WorkerName = "worker-001"
+		controllerutil.AddFinalizer(&problemEnvironment, netconv1alpha1.ProblemEnvironmentFinalizer)
+		err = k8sClient.Create(ctx, &problemEnvironment)
+		Expect(err).NotTo(HaveOccurred())
+
+		status := metav1.ConditionFalse
+		if assigned {
+			status = metav1.ConditionTrue
+		}
+		util.SetProblemEnvironmentCondition(
+			&problemEnvironment,
+			netconv1alpha1.ProblemEnvironmentConditionAssigned,
+			status,
+			"Test", "test",
+		)
+		err = k8sClient.Status().Update(ctx, &problemEnvironment)
+		Expect(err).NotTo(HaveOccurred())
+
+		return problemEnvironment
+	}
+
+	It("should evict ProblemEnvironments from the Worker NotReady longer than grace period", func() {
+		worker := netconv1alpha1.Worker{}
+		worker.Name = "worker-001"
+		err := k8sClient.Create(ctx, &worker)
+		Expect(err).NotTo(HaveOccurred())
+
+		util.SetWorkerCondition(
+			&worker,
+			netconv1alpha1.WorkerConditionReady,
+			metav1.ConditionFalse,
+			"HealthCheckFail",
+			"failed to check health",
+		)
+		worker.Status.Conditions[0].LastTransitionTime = metav1.NewTime(time.Now().Add(-10 * time.Minute))
+		err = k8sClient.Status().Update(ctx, &worker)
+		Expect(err).NotTo(HaveOccurred())
+
+		unassigned := createProblemEnvironment("problemenvironment-tst-001.yaml", false)
+		assigned := createProblemEnvironment("problemenvironment-tst-002.yaml", true)
+
+		wc := NewWorkerController(k8sClient, 3*time.Second, 5*time.Minute, record.NewFakeRecorder(10))
+		err = wc.evictProblemEnvironments(ctx, &worker)
+		Expect(err).NotTo(HaveOccurred())
+
+		err = k8sClient.Get(ctx, client.ObjectKeyFromObject(&unassigned), &unassigned)
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+
+		err = k8sClient.Get(ctx, types.NamespacedName{
+			Namespace: assigned.Namespace,
+			Name:      assigned.Name,
+		}, &assigned)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(util.GetProblemEnvironmentCondition(
+			&assigned,
+			netconv1alpha1.ProblemEnvironmentConditionWorkerLost,
+		)).To(Equal(metav1.ConditionTrue))
+
+		controllerutil.RemoveFinalizer(&assigned, netconv1alpha1.ProblemEnvironmentFinalizer)
+		err = k8sClient.Update(ctx, &assigned)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should not evict ProblemEnvironments within grace period", func() {
+		worker := netconv1alpha1.Worker{}
+		worker.Name = "worker-001"
+		err := k8sClient.Create(ctx, &worker)
+		Expect(err).NotTo(HaveOccurred())
+
+		util.SetWorkerCondition(
+			&worker,
+			netconv1alpha1.WorkerConditionReady,
+			metav1.ConditionFalse,
+			"HealthCheckFail",
+			"failed to check health",
+		)
+		err = k8sClient.Status().Update(ctx, &worker)
+		Expect(err).NotTo(HaveOccurred())
+
+		unassigned := createProblemEnvironment("problemenvironment-tst-001.yaml", false)
+
+		wc := NewWorkerController(k8sClient, 3*time.Second, 5*time.Minute, record.NewFakeRecorder(10))
+		err = wc.evictProblemEnvironments(ctx, &worker)
+		Expect(err).NotTo(HaveOccurred())
+
+		err = k8sClient.Get(ctx, client.ObjectKeyFromObject(&unassigned), &unassigned)
+		Expect(err).NotTo(HaveOccurred())
+
+		controllerutil.RemoveFinalizer(&unassigned, netconv1alpha1.ProblemEnvironmentFinalizer)
+		err = k8sClient.Update(ctx, &unassigned)
+		Expect(err).NotTo(HaveOccurred())
+	})
+})
@@ -34,6 +34,39 @@ func (e ErrNoAvailableProblemEnvironment) Error() string {
 	return "No available ProblemEnvironment for: " + e.problemName
 }
 
+type ErrProblemEnvironmentNotAssigned struct {
+	name string
+}
+
+func (e ErrProblemEnvironmentNotAssigned) Error() string {
+	return "ProblemEnvironment not assigned: " + e.name
+}
+
+type ErrAssignmentNotExpirable struct {
+	name string
+}
+
+func (e ErrAssignmentNotExpirable) Error() string {
+	return "Assignment never expires: " + e.name
+}
+
+type ErrProblemEnvironmentResetting struct {
+	name string
+}
+
+func (e ErrProblemEnvironmentResetting) Error() string {
+	return "ProblemEnvironment is being reset: " + e.name
+}
+
+type ErrInvalidAssignee struct {
+	id     string
+	reason string
+}
+
+func (e ErrInvalidAssignee) Error() string {
+	return "Invalid assignee ID " + e.id + ": " + e.reason
+}
+
 func AsErrProblemNotFound(err error) (*ErrProblemNotFound, bool) {
 	target := ErrProblemNotFound{}
 	if errors.As(err, &target) {
@@ -65,3 +98,35 @@ func AsErrNoAvailableProblemEnvironment(err error) (*ErrNoAvailableProblemEnviro
 	}
 	return nil, false
 }
+
+func AsErrProblemEnvironmentNotAssigned(err error) (*ErrProblemEnvironmentNotAssigned, bool) {
+	target := ErrProblemEnvironmentNotAssigned{}
+	if errors.As(err, &target) {
+		return &target, true
+	}
+	return nil, false
+}
+
+func AsErrAssignmentNotExpirable(err error) (*ErrAssignmentNotExpirable, bool) {
+	target := ErrAssignmentNotExpirable{}
+	if errors.As(err, &target) {
+		return &target, true
+	}
+	return nil, false
+}
+
+func AsErrInvalidAssignee(err error) (*ErrInvalidAssignee, bool) {
+	target := ErrInvalidAssignee{}
+	if errors.As(err, &target) {
+		return &target, true
+	}
+	return nil, false
+}
+
+func AsErrProblemEnvironmentResetting(err error) (*ErrProblemEnvironmentResetting, bool) {
+	target := ErrProblemEnvironmentResetting{}
+	if errors.As(err, &target) {
+		return &target, true
+	}
+	return nil, false
+}
@@ -35,6 +35,8 @@ func (g *Gateway) Start(ctx context.Context) error {
 	r.Get("/problem/{name}", g.GetProblemEnvironmentController)
 	r.Post("/problem", g.AcquireProblemEnvironmentHandler)
 	r.Delete("/problem/{name}", g.ReleaseProblemEnvironmentHandler)
+	r.Post("/problem/{name}/extend", g.ExtendProblemEnvironmentHandler)
+	r.Post("/problem/{name}/reset", g.ResetProblemEnvironmentHandler)
 
 	server := http.Server{
 		Addr: ":8082",
@@ -110,13 +112,19 @@ func (g *Gateway) AcquireProblemEnvironmentHandler(w http.ResponseWriter, r *htt
 		return
 	}
 
-	problemEnvironment, err := g.AcquireProblemEnvironment(ctx, request.ProblemName)
+	problemEnvironment, err := g.AcquireProblemEnvironment(ctx, request.ProblemName, request.Assignee)
 	if err != nil {
 		if _, ok := AsErrProblemNotFound(err); ok {
 			w.WriteHeader(http.StatusNotFound)
 			return
 		}
 
+		if _, ok := AsErrInvalidAssignee(err); ok {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(err.Error()))
+			return
+		}
+
 		if _, ok := AsErrNoAvailableProblemEnvironment(err); ok {
 			w.WriteHeader(http.StatusServiceUnavailable)
 			return
@@ -158,6 +166,46 @@ func (g *Gateway) ReleaseProblemEnvironmentHandler(w http.ResponseWriter, r *htt
 	w.WriteHeader(http.StatusOK)
 }
 
+func (g *Gateway) ExtendProblemEnvironmentHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	name := chi.URLParam(r, "name")
+
+	problemEnvironment, err := g.ExtendProblemEnvironment(ctx, name)
+	if err != nil {
+		if _, ok := AsErrProblemEnvironmentNotFound(err); ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		if _, ok := AsErrProblemEnvironmentNotAssigned(err); ok {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+
+		if _, ok := AsErrAssignmentNotExpirable(err); ok {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("assignment never expires"))
+			return
+		}
+
+		if _, ok := AsErrWorkerNotFound(err); ok {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte("worker not found"))
+			return
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	response := ExtendProblemEnvironmentResponse(*problemEnvironment)
+	if err := renderJSON(w, response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
 func bindJSON[T any](r *http.Request, v T) error {
 	decoder := json.NewDecoder(r.Body)
 	decoder.DisallowUnknownFields()
@@ -180,3 +228,43 @@ func renderJSON[T any](w http.ResponseWriter, response T) error {
 	w.Write(buf.Bytes())
 	return nil
 }
+
+func (g *Gateway) ResetProblemEnvironmentHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	name := chi.URLParam(r, "name")
+
+	problemEnvironment, err := g.ResetProblemEnvironment(ctx, name)
+	if err != nil {
+		if _, ok := AsErrProblemEnvironmentNotFound(err); ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		if _, ok := AsErrProblemEnvironmentNotAssigned(err); ok {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+
+		if _, ok := AsErrProblemEnvironmentResetting(err); ok {
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte("already being reset"))
+			return
+		}
+
+		if _, ok := AsErrWorkerNotFound(err); ok {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte("worker not found"))
+			return
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	response := ResetProblemEnvironmentResponse(*problemEnvironment)
+	if err := renderJSON(w, response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
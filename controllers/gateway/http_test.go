@@ -0,0 +1,256 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	netconv1alpha1 "github.com/janog-netcon/netcon-problem-management-subsystem/api/v1alpha1"
+	"github.com/janog-netcon/netcon-problem-management-subsystem/pkg/util"
+)
+
+func newTestGateway(t *testing.T, objects ...client.Object) *Gateway {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := netconv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	client := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(objects...).
+		WithStatusSubresource(&netconv1alpha1.ProblemEnvironment{}, &netconv1alpha1.Problem{}).
+		Build()
+
+	return &Gateway{
+		Client:   client,
+		Recorder: record.NewFakeRecorder(10),
+	}
+}
+
+func newTestProblem(assignmentTTL time.Duration) *netconv1alpha1.Problem {
+	problem := &netconv1alpha1.Problem{}
+	problem.Namespace = "netcon"
+	problem.Name = "tst-001"
+	if assignmentTTL > 0 {
+		problem.Spec.AssignmentTTL = &metav1.Duration{Duration: assignmentTTL}
+	}
+	return problem
+}
+
+func newTestProblemEnvironment(assigned bool) *netconv1alpha1.ProblemEnvironment {
+	problemEnvironment := &netconv1alpha1.ProblemEnvironment{}
+	problemEnvironment.Namespace = "netcon"
+	problemEnvironment.Name = "tst-001-abcde"
+	problemEnvironment.Labels = map[string]string{netconv1alpha1.LabelProblemName: "tst-001"}
+	problemEnvironment.Spec.WorkerName = "worker-001"
+	problemEnvironment.Status.Password = "password"
+
+	status := metav1.ConditionFalse
+	if assigned {
+		status = metav1.ConditionTrue
+	}
+	util.SetProblemEnvironmentCondition(
+		problemEnvironment,
+		netconv1alpha1.ProblemEnvironmentConditionAssigned,
+		status,
+		"Test", "test",
+	)
+	return problemEnvironment
+}
+
+func newTestGatewayWorker() *netconv1alpha1.Worker {
+	worker := &netconv1alpha1.Worker{}
+	worker.Name = "worker-001"
+	worker.Status.WorkerInfo.ExternalIPAddress = "192.0.2.1"
+	worker.Status.WorkerInfo.ExternalPort = 2222
+	return worker
+}
+
+func serveTestRequest(g *Gateway, method, pattern, target string, handler http.HandlerFunc) *httptest.ResponseRecorder {
+	r := chi.NewRouter()
+	r.MethodFunc(method, pattern, handler)
+
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, httptest.NewRequest(method, target, nil))
+	return recorder
+}
+
+func getTestProblemEnvironment(t *testing.T, g *Gateway) *netconv1alpha1.ProblemEnvironment {
+	problemEnvironment := &netconv1alpha1.ProblemEnvironment{}
+	if err := g.Get(context.Background(), types.NamespacedName{
+		Namespace: "netcon",
+		Name:      "tst-001-abcde",
+	}, problemEnvironment); err != nil {
+		t.Fatal(err)
+	}
+	return problemEnvironment
+}
+
+func TestExtendProblemEnvironmentHandler(t *testing.T) {
+	extend := func(g *Gateway) *httptest.ResponseRecorder {
+		return serveTestRequest(
+			g, http.MethodPost, "/problem/{name}/extend", "/problem/tst-001-abcde/extend",
+			g.ExtendProblemEnvironmentHandler,
+		)
+	}
+
+	t.Run("extended", func(t *testing.T) {
+		g := newTestGateway(t, newTestProblem(time.Hour), newTestProblemEnvironment(true), newTestGatewayWorker())
+
+		recorder := extend(g)
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("unexpected status code: %d", recorder.Code)
+		}
+
+		response := ExtendProblemEnvironmentResponse{}
+		if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+			t.Fatal(err)
+		}
+		if response.Host != "192.0.2.1" || response.Port != 2222 {
+			t.Errorf("unexpected address: %s:%d", response.Host, response.Port)
+		}
+		if response.RemainingSeconds == nil || *response.RemainingSeconds < 3590 {
+			t.Errorf("unexpected remaining seconds: %v", response.RemainingSeconds)
+		}
+
+		problemEnvironment := getTestProblemEnvironment(t, g)
+		if problemEnvironment.Status.AssignmentExpiresAt == nil {
+			t.Errorf("AssignmentExpiresAt should be set")
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		g := newTestGateway(t, newTestProblem(time.Hour), newTestGatewayWorker())
+
+		if recorder := extend(g); recorder.Code != http.StatusNotFound {
+			t.Fatalf("unexpected status code: %d", recorder.Code)
+		}
+	})
+
+	t.Run("not assigned", func(t *testing.T) {
+		g := newTestGateway(t, newTestProblem(time.Hour), newTestProblemEnvironment(false), newTestGatewayWorker())
+
+		if recorder := extend(g); recorder.Code != http.StatusConflict {
+			t.Fatalf("unexpected status code: %d", recorder.Code)
+		}
+	})
+
+	t.Run("not expirable", func(t *testing.T) {
+		g := newTestGateway(t, newTestProblem(0), newTestProblemEnvironment(true), newTestGatewayWorker())
+
+		if recorder := extend(g); recorder.Code != http.StatusBadRequest {
+			t.Fatalf("unexpected status code: %d", recorder.Code)
+		}
+	})
+
+	t.Run("worker not found", func(t *testing.T) {
+		g := newTestGateway(t, newTestProblem(time.Hour), newTestProblemEnvironment(true))
+
+		if recorder := extend(g); recorder.Code != http.StatusInternalServerError {
+			t.Fatalf("unexpected status code: %d", recorder.Code)
+		}
+
+		// the assignment must not be extended when the request fails
+		problemEnvironment := getTestProblemEnvironment(t, g)
+		if problemEnvironment.Status.AssignmentExpiresAt != nil {
+			t.Errorf("AssignmentExpiresAt should not be set")
+		}
+	})
+}
+
+func TestResetProblemEnvironmentHandler(t *testing.T) {
+	reset := func(g *Gateway) *httptest.ResponseRecorder {
+		return serveTestRequest(
+			g, http.MethodPost, "/problem/{name}/reset", "/problem/tst-001-abcde/reset",
+			g.ResetProblemEnvironmentHandler,
+		)
+	}
+
+	t.Run("reset requested", func(t *testing.T) {
+		g := newTestGateway(t, newTestProblem(0), newTestProblemEnvironment(true), newTestGatewayWorker())
+
+		recorder := reset(g)
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("unexpected status code: %d", recorder.Code)
+		}
+
+		response := ResetProblemEnvironmentResponse{}
+		if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+			t.Fatal(err)
+		}
+		if response.Name != "tst-001-abcde" || response.Password != "password" {
+			t.Errorf("unexpected response: %+v", response)
+		}
+
+		problemEnvironment := getTestProblemEnvironment(t, g)
+		if problemEnvironment.Spec.ResetGeneration != 1 {
+			t.Errorf("unexpected ResetGeneration: %d", problemEnvironment.Spec.ResetGeneration)
+		}
+		if !util.IsProblemEnvironmentResetRequested(problemEnvironment) {
+			t.Errorf("reset should be requested")
+		}
+	})
+
+	t.Run("already resetting", func(t *testing.T) {
+		problemEnvironment := newTestProblemEnvironment(true)
+		problemEnvironment.Spec.ResetGeneration = 1
+		g := newTestGateway(t, newTestProblem(0), problemEnvironment, newTestGatewayWorker())
+
+		if recorder := reset(g); recorder.Code != http.StatusConflict {
+			t.Fatalf("unexpected status code: %d", recorder.Code)
+		}
+		if problemEnvironment := getTestProblemEnvironment(t, g); problemEnvironment.Spec.ResetGeneration != 1 {
+			t.Errorf("ResetGeneration should not be bumped again: %d", problemEnvironment.Spec.ResetGeneration)
+		}
+	})
+
+	t.Run("reset again after the previous reset", func(t *testing.T) {
+		problemEnvironment := newTestProblemEnvironment(true)
+		problemEnvironment.Spec.ResetGeneration = 1
+		problemEnvironment.Status.ObservedResetGeneration = 1
+		g := newTestGateway(t, newTestProblem(0), problemEnvironment, newTestGatewayWorker())
+
+		if recorder := reset(g); recorder.Code != http.StatusOK {
+			t.Fatalf("unexpected status code: %d", recorder.Code)
+		}
+		if problemEnvironment := getTestProblemEnvironment(t, g); problemEnvironment.Spec.ResetGeneration != 2 {
+			t.Errorf("unexpected ResetGeneration: %d", problemEnvironment.Spec.ResetGeneration)
+		}
+	})
+
+	t.Run("not assigned", func(t *testing.T) {
+		g := newTestGateway(t, newTestProblem(0), newTestProblemEnvironment(false), newTestGatewayWorker())
+
+		if recorder := reset(g); recorder.Code != http.StatusConflict {
+			t.Fatalf("unexpected status code: %d", recorder.Code)
+		}
+	})
+
+	t.Run("worker not found", func(t *testing.T) {
+		g := newTestGateway(t, newTestProblem(0), newTestProblemEnvironment(true))
+
+		if recorder := reset(g); recorder.Code != http.StatusInternalServerError {
+			t.Fatalf("unexpected status code: %d", recorder.Code)
+		}
+
+		// the reset must not be requested when the request fails
+		if problemEnvironment := getTestProblemEnvironment(t, g); problemEnvironment.Spec.ResetGeneration != 0 {
+			t.Errorf("ResetGeneration should not be bumped: %d", problemEnvironment.Spec.ResetGeneration)
+		}
+	})
+}
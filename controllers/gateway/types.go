@@ -1,17 +1,35 @@
 package controllers
 
+import "time"
+
 type ProblemEnvironment struct {
 	Name     string `json:"name"`
 	Host     string `json:"host"`
 	Port     uint16 `json:"port"`
 	User     string `json:"user"`
 	Password string `json:"password"`
+
+	// ExpiresAt and RemainingSeconds are set only when the assignment expires
+	ExpiresAt        *time.Time `json:"expiresAt,omitempty"`
+	RemainingSeconds *int64     `json:"remainingSeconds,omitempty"`
 }
 
 type GetProblemEnvironmentResponse ProblemEnvironment
 
+type Assignee struct {
+	ID       string            `json:"id"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
 type AcquireProblemEnvironmentRequest struct {
 	ProblemName string `json:"problemName"`
+
+	// Assignee is optional for the compatibility with the score server not sending it
+	Assignee *Assignee `json:"assignee,omitempty"`
 }
 
 type AcquireProblemEnvironmentResponse ProblemEnvironment
+
+type ExtendProblemEnvironmentResponse ProblemEnvironment
+
+type ResetProblemEnvironmentResponse ProblemEnvironment
@@ -3,6 +3,8 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"strings"
+	"time"
 
 	netconv1alpha1 "github.com/janog-netcon/netcon-problem-management-subsystem/api/v1alpha1"
 	"github.com/janog-netcon/netcon-problem-management-subsystem/pkg/util"
@@ -10,17 +12,40 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/util/retry"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
 )
 
 func getProblemEnvironmentFor(problemEnvironment netconv1alpha1.ProblemEnvironment, worker netconv1alpha1.Worker) *ProblemEnvironment {
-	return &ProblemEnvironment{
+	response := &ProblemEnvironment{
 		Name:     problemEnvironment.Name,
 		Host:     worker.Status.WorkerInfo.ExternalIPAddress,
 		Port:     worker.Status.WorkerInfo.ExternalPort,
 		User:     fmt.Sprintf("nc_%s", problemEnvironment.Name),
 		Password: problemEnvironment.Status.Password,
 	}
+
+	if expiresAt := problemEnvironment.Status.AssignmentExpiresAt; expiresAt != nil {
+		remainingSeconds := max(int64(time.Until(expiresAt.Time).Seconds()), 0)
+		response.ExpiresAt = &expiresAt.Time
+		response.RemainingSeconds = &remainingSeconds
+	}
+
+	return response
+}
+
+// extendAssignment sets the expiry of the assignment to AssignmentTTL of the Problem from now.
+// It does nothing if AssignmentTTL is not set.
+func extendAssignment(problemEnvironment *netconv1alpha1.ProblemEnvironment, problem *netconv1alpha1.Problem) bool {
+	if problem.Spec.AssignmentTTL == nil || problem.Spec.AssignmentTTL.Duration <= 0 {
+		return false
+	}
+
+	expiresAt := metav1.NewTime(time.Now().Add(problem.Spec.AssignmentTTL.Duration))
+	problemEnvironment.Status.AssignmentExpiresAt = &expiresAt
+	return true
 }
 
 func (g *Gateway) GetProblemEnvironment(ctx context.Context, name string) (*ProblemEnvironment, error) {
@@ -43,7 +68,40 @@ func (g *Gateway) GetProblemEnvironment(ctx context.Context, name string) (*Prob
 	return getProblemEnvironmentFor(problemEnvironment, worker), nil
 }
 
-func (g *Gateway) AcquireProblemEnvironment(ctx context.Context, problemName string) (*ProblemEnvironment, error) {
+// validateAssignee checks whether the assignee ID can be used as the value of LabelAssignee.
+func validateAssignee(assignee *Assignee) error {
+	if assignee == nil {
+		return nil
+	}
+	if assignee.ID == "" {
+		return ErrInvalidAssignee{assignee.ID, "must not be empty"}
+	}
+	if errs := validation.IsValidLabelValue(assignee.ID); len(errs) != 0 {
+		return ErrInvalidAssignee{assignee.ID, strings.Join(errs, ", ")}
+	}
+	return nil
+}
+
+// setAssigneeLabel sets LabelAssignee to the ProblemEnvironment so that it can be queried by the assignee.
+func (g *Gateway) setAssigneeLabel(ctx context.Context, problemEnvironment *netconv1alpha1.ProblemEnvironment) error {
+	assignee := problemEnvironment.Status.Assignee
+	if assignee == nil {
+		return nil
+	}
+
+	patch := client.MergeFrom(problemEnvironment.DeepCopy())
+	if problemEnvironment.Labels == nil {
+		problemEnvironment.Labels = map[string]string{}
+	}
+	problemEnvironment.Labels[netconv1alpha1.LabelAssignee] = assignee.ID
+	return g.Patch(ctx, problemEnvironment, patch)
+}
+
+func (g *Gateway) AcquireProblemEnvironment(ctx context.Context, problemName string, assignee *Assignee) (*ProblemEnvironment, error) {
+	if err := validateAssignee(assignee); err != nil {
+		return nil, err
+	}
+
 	problem := netconv1alpha1.Problem{}
 	if err := g.Get(ctx, types.NamespacedName{Namespace: "netcon", Name: problemName}, &problem); err != nil {
 		if errors.IsNotFound(err) {
@@ -52,6 +110,14 @@ func (g *Gateway) AcquireProblemEnvironment(ctx context.Context, problemName str
 		return nil, err
 	}
 
+	// demand is recorded even if no ProblemEnvironment is available, so that Autoscaling can catch up.
+	// It's recorded only for Problems with Autoscaling not to write the status on every acquisition.
+	if problem.Spec.Autoscaling != nil {
+		if err := g.recordAcquisition(ctx, &problem); err != nil {
+			log.FromContext(ctx).Error(err, "failed to record acquisition", "problem", problemName)
+		}
+	}
+
 	problemEnvironments := netconv1alpha1.ProblemEnvironmentList{}
 	if err := g.List(ctx, &problemEnvironments, client.MatchingLabels{
 		"problemName": problemName,
@@ -79,10 +145,23 @@ func (g *Gateway) AcquireProblemEnvironment(ctx context.Context, problemName str
 		"Assigned",
 		"Assigned ProblemEnvironment",
 	)
+	extendAssignment(selected, &problem)
+	if assignee != nil {
+		selected.Status.Assignee = &netconv1alpha1.Assignee{
+			ID:       assignee.ID,
+			Metadata: assignee.Metadata,
+		}
+	}
 	if err := g.Status().Update(ctx, selected); err != nil {
 		return nil, err
 	}
 
+	// the assignment has been already committed by updating status, so it's not rolled back.
+	// The assignee can be still found in status even if the label is missing.
+	if err := g.setAssigneeLabel(ctx, selected); err != nil {
+		log.FromContext(ctx).Error(err, "failed to set assignee label", "problemEnvironment", selected.Name)
+	}
+
 	g.Recorder.Event(
 		selected,
 		corev1.EventTypeNormal,
@@ -101,6 +180,113 @@ func (g *Gateway) AcquireProblemEnvironment(ctx context.Context, problemName str
 	return getProblemEnvironmentFor(*selected, worker), nil
 }
 
+func (g *Gateway) ExtendProblemEnvironment(ctx context.Context, name string) (*ProblemEnvironment, error) {
+	problemEnvironment := netconv1alpha1.ProblemEnvironment{}
+	if err := g.Get(ctx, types.NamespacedName{Namespace: "netcon", Name: name}, &problemEnvironment); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, ErrProblemEnvironmentNotFound{name}
+		}
+		return nil, err
+	}
+
+	isAssigned := util.GetProblemEnvironmentCondition(&problemEnvironment, netconv1alpha1.ProblemEnvironmentConditionAssigned)
+	if isAssigned != metav1.ConditionTrue || problemEnvironment.DeletionTimestamp != nil {
+		return nil, ErrProblemEnvironmentNotAssigned{name}
+	}
+
+	problemName := problemEnvironment.Labels[netconv1alpha1.LabelProblemName]
+	problem := netconv1alpha1.Problem{}
+	if err := g.Get(ctx, types.NamespacedName{Namespace: "netcon", Name: problemName}, &problem); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, ErrProblemNotFound{problemName}
+		}
+		return nil, err
+	}
+
+	// Worker is fetched before extending the assignment
+	// not to fail after the assignment has been extended
+	worker := netconv1alpha1.Worker{}
+	if err := g.Get(ctx, types.NamespacedName{Name: problemEnvironment.Spec.WorkerName}, &worker); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, ErrWorkerNotFound{problemEnvironment.Spec.WorkerName}
+		}
+		return nil, err
+	}
+
+	if !extendAssignment(&problemEnvironment, &problem) {
+		return nil, ErrAssignmentNotExpirable{name}
+	}
+	if err := g.Status().Update(ctx, &problemEnvironment); err != nil {
+		return nil, err
+	}
+
+	g.Recorder.Eventf(
+		&problemEnvironment,
+		corev1.EventTypeNormal,
+		netconv1alpha1.ProblemEnvironmentEventAssignmentExtended,
+		"Assignment extended until %s",
+		problemEnvironment.Status.AssignmentExpiresAt.Format(time.RFC3339),
+	)
+
+	return getProblemEnvironmentFor(problemEnvironment, worker), nil
+}
+
+func (g *Gateway) ResetProblemEnvironment(ctx context.Context, name string) (*ProblemEnvironment, error) {
+	problemEnvironment := netconv1alpha1.ProblemEnvironment{}
+	if err := g.Get(ctx, types.NamespacedName{Namespace: "netcon", Name: name}, &problemEnvironment); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, ErrProblemEnvironmentNotFound{name}
+		}
+		return nil, err
+	}
+
+	isAssigned := util.GetProblemEnvironmentCondition(&problemEnvironment, netconv1alpha1.ProblemEnvironmentConditionAssigned)
+	if isAssigned != metav1.ConditionTrue || problemEnvironment.DeletionTimestamp != nil {
+		return nil, ErrProblemEnvironmentNotAssigned{name}
+	}
+
+	// requesting reset again while resetting would destroy ProblemEnvironment being redeployed
+	if util.IsProblemEnvironmentResetting(&problemEnvironment) {
+		return nil, ErrProblemEnvironmentResetting{name}
+	}
+
+	// Worker is fetched before requesting the reset
+	// not to fail after the reset has been requested
+	worker := netconv1alpha1.Worker{}
+	if err := g.Get(ctx, types.NamespacedName{Name: problemEnvironment.Spec.WorkerName}, &worker); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, ErrWorkerNotFound{problemEnvironment.Spec.WorkerName}
+		}
+		return nil, err
+	}
+
+	util.RequestProblemEnvironmentReset(&problemEnvironment)
+	if err := g.Update(ctx, &problemEnvironment); err != nil {
+		return nil, err
+	}
+
+	g.Recorder.Event(
+		&problemEnvironment,
+		corev1.EventTypeNormal,
+		netconv1alpha1.ProblemEnvironmentEventResetting,
+		"Reset requested",
+	)
+
+	return getProblemEnvironmentFor(problemEnvironment, worker), nil
+}
+
+// recordAcquisition counts the acquisition in the status of Problem, which drives Autoscaling.
+func (g *Gateway) recordAcquisition(ctx context.Context, problem *netconv1alpha1.Problem) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if err := g.Get(ctx, client.ObjectKeyFromObject(problem), problem); err != nil {
+			return err
+		}
+
+		util.RecordProblemAcquisition(problem, time.Now())
+		return g.Status().Update(ctx, problem)
+	})
+}
+
 func (g *Gateway) ReleaseProblemEnvironment(ctx context.Context, name string) error {
 	problemEnvironment := netconv1alpha1.ProblemEnvironment{}
 	if err := g.Get(ctx, types.NamespacedName{Namespace: "netcon", Name: name}, &problemEnvironment); err != nil {
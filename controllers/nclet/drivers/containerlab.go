@@ -3,13 +3,20 @@ package drivers
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
+	"unicode/utf8"
 
 	dockerTypes "github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/api/types/network"
 	dockerClient "github.com/docker/docker/client"
 	"gopkg.in/yaml.v3"
@@ -21,19 +28,36 @@ import (
 
 	netconv1alpha1 "github.com/janog-netcon/netcon-problem-management-subsystem/api/v1alpha1"
 	"github.com/janog-netcon/netcon-problem-management-subsystem/pkg/containerlab"
+	"github.com/janog-netcon/netcon-problem-management-subsystem/pkg/util"
 )
 
+// DefaultDeployLogHistoryLimit is the default number of deploy logs kept per ProblemEnvironment
+const DefaultDeployLogHistoryLimit = 5
+
+// maxDeployLogOutputSize caps each output in the deploy log to stay under the size limit of ConfigMap (1MiB)
+const maxDeployLogOutputSize = 256 * 1024
+
 type ContainerLabProblemEnvironmentDriver struct {
 	configDir    string
 	dockerClient dockerClient.APIClient
+	prober       *readinessProber
+
+	// deployLogHistoryLimit is the number of deploy logs kept per ProblemEnvironment
+	deployLogHistoryLimit int
 }
 
 var _ ProblemEnvironmentDriver = &ContainerLabProblemEnvironmentDriver{}
 
-func NewContainerLabProblemEnvironmentDriver(configDir string, dockerClient dockerClient.APIClient) *ContainerLabProblemEnvironmentDriver {
+func NewContainerLabProblemEnvironmentDriver(
+	configDir string,
+	dockerClient dockerClient.APIClient,
+	deployLogHistoryLimit int,
+) *ContainerLabProblemEnvironmentDriver {
 	return &ContainerLabProblemEnvironmentDriver{
-		configDir:    configDir,
-		dockerClient: dockerClient,
+		configDir:             configDir,
+		deployLogHistoryLimit: deployLogHistoryLimit,
+		dockerClient:          dockerClient,
+		prober:                newReadinessProber(dockerClient),
 	}
 }
 
@@ -63,7 +87,8 @@ func (d *ContainerLabProblemEnvironmentDriver) delete(path string) (bool, error)
 	return true, os.RemoveAll(path)
 }
 
-func (d *ContainerLabProblemEnvironmentDriver) fetchFile(
+// fetchFile fetches the file of ProblemEnvironment from ConfigMap
+func fetchFile(
 	ctx context.Context,
 	reader client.Reader,
 	problemEnvironment *netconv1alpha1.ProblemEnvironment,
@@ -100,7 +125,7 @@ func (d *ContainerLabProblemEnvironmentDriver) getTopologyFileFor(
 ) ([]byte, error) {
 	log := log.FromContext(ctx)
 
-	topology, err := d.fetchFile(ctx, reader, problemEnvironment, &problemEnvironment.Spec.TopologyFile)
+	topology, err := fetchFile(ctx, reader, problemEnvironment, &problemEnvironment.Spec.TopologyFile)
 	if err != nil {
 		log.Error(err, "failed to load topology file")
 		return nil, err
@@ -176,7 +201,7 @@ func (d *ContainerLabProblemEnvironmentDriver) placeFiles(
 
 	// place config file
 	for _, config := range problemEnvironment.Spec.ConfigFiles {
-		data, err := d.fetchFile(ctx, reader, problemEnvironment, &config)
+		data, err := fetchFile(ctx, reader, problemEnvironment, &config)
 		if err != nil {
 			return err
 		}
@@ -220,42 +245,67 @@ func (d *ContainerLabProblemEnvironmentDriver) Check(
 	}
 
 	containerPrefix := fmt.Sprintf("clab-%s-", problemEnvironment.Name)
-	containerStatuses := []netconv1alpha1.ContainerStatus{}
-	for _, c := range containers {
-		name := strings.ReplaceAll(c.Name, containerPrefix, "")
-		containerStatus := netconv1alpha1.ContainerStatus{
-			Name:                name,
-			Image:               c.Image,
-			ContainerID:         c.ContainerID,
-			ManagementIPAddress: c.IPv4Address,
-		}
+	containerStatuses := make([]netconv1alpha1.ContainerStatus, len(containers))
 
-		containerInfo, err := d.dockerClient.ContainerInspect(ctx, c.ContainerID)
-		if err != nil {
-			log.Error(err, "failed to fetch container information from docker daemon")
-			containerStatuses = append(containerStatuses, containerStatus)
-			continue
-		}
+	// containers are checked concurrently not to wait for the readiness probes one by one
+	wg := sync.WaitGroup{}
+	for i, c := range containers {
+		wg.Add(1)
+		go func(i int, c containerlab.ContainerDetails) {
+			defer wg.Done()
+			containerStatuses[i] = d.checkContainer(ctx, c, strings.ReplaceAll(c.Name, containerPrefix, ""))
+		}(i, c)
+	}
+	wg.Wait()
 
-		ready := false
-		if containerInfo.State.Running {
-			if containerInfo.State.Health == nil {
-				// If containerInfo doesn't have Health, we can consider the container is ready
-				ready = true
-			} else if containerInfo.State.Health.Status == "healthy" {
-				// If Health.Status is "healthy", we can consider the container is ready
-				// ref: https://pkg.go.dev/github.com/docker/docker/api/types#Health
-				ready = true
-			}
-		}
+	return StatusDeployed, containerStatuses
+}
 
-		containerStatus.ContainerName = containerInfo.Name
-		containerStatus.Ready = ready
+func (d *ContainerLabProblemEnvironmentDriver) checkContainer(
+	ctx context.Context,
+	c containerlab.ContainerDetails,
+	name string,
+) netconv1alpha1.ContainerStatus {
+	log := log.FromContext(ctx)
 
-		containerStatuses = append(containerStatuses, containerStatus)
+	containerStatus := netconv1alpha1.ContainerStatus{
+		Name:                name,
+		Image:               c.Image,
+		ContainerID:         c.ContainerID,
+		ManagementIPAddress: c.IPv4Address,
 	}
 
-	return StatusDeployed, containerStatuses
+	containerInfo, err := d.dockerClient.ContainerInspect(ctx, c.ContainerID)
+	if err != nil {
+		log.Error(err, "failed to fetch container information from docker daemon")
+		return containerStatus
+	}
+
+	ready := false
+	if containerInfo.State.Running {
+		if containerInfo.State.Health == nil {
+			// If containerInfo doesn't have Health, we can consider the container is ready
+			ready = true
+		} else if containerInfo.State.Health.Status == "healthy" {
+			// If Health.Status is "healthy", we can consider the container is ready
+			// ref: https://pkg.go.dev/github.com/docker/docker/api/types#Health
+			ready = true
+		}
+	}
+
+	// NOS may be running long before it's usable, so the readiness probe is also required
+	if ready {
+		ready, err = d.prober.ready(ctx, containerInfo, c.IPv4Address)
+		if err != nil {
+			log.Error(err, "failed to parse readiness probe", "container", c.Name)
+		}
+	}
+
+	containerStatus.ContainerName = containerInfo.Name
+	containerStatus.Running = containerInfo.State.Running
+	containerStatus.Ready = ready
+
+	return containerStatus
 }
 
 // Deploy implements ProblemEnvironmentDriver
@@ -296,24 +346,126 @@ func (d *ContainerLabProblemEnvironmentDriver) deploy(
 		log.V(1).Info("finished deploying", "elapsed", endedAt.Sub(startedAt))
 	}
 
+	result := DeployResultSucceeded
+	if err != nil {
+		result = DeployResultFailed
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			result = DeployResultTimedOut
+		}
+	}
+
 	configMap := corev1.ConfigMap{}
 	configMap.Namespace = problemEnvironment.Namespace
-	configMap.Name = fmt.Sprintf("deploy-%s-%d", problemEnvironment.Name, startedAt.Unix())
+	// the name is generated not to conflict with the deploy log of the previous attempt made in the same second
+	configMap.GenerateName = fmt.Sprintf("deploy-%s-", problemEnvironment.Name)
+	configMap.Labels = map[string]string{
+		netconv1alpha1.LabelDeployLogFor:          problemEnvironment.Name,
+		netconv1alpha1.LabelDeployAttempt:         strconv.Itoa(problemEnvironment.Status.DeployAttempts + 1),
+		netconv1alpha1.LabelDeployResetGeneration: strconv.FormatInt(problemEnvironment.Status.ObservedResetGeneration, 10),
+	}
 	configMap.Data = map[string]string{
-		"stdout":    string(stdout),
-		"stderr":    string(stderr),
+		"stdout":    truncateMiddle(stdout, maxDeployLogOutputSize),
+		"stderr":    truncateMiddle(stderr, maxDeployLogOutputSize),
 		"startedAt": startedAt.Format(time.RFC3339Nano),
 		"endedAt":   endedAt.Format(time.RFC3339Nano),
+		"result":    result,
+	}
+	if err != nil {
+		configMap.Data["error"] = err.Error()
 	}
 	controllerutil.SetOwnerReference(problemEnvironment, &configMap, client.Scheme())
 
-	if err := client.Create(ctx, &configMap); err != nil {
-		log.Info("failed to record deploy log")
+	// ctx may be already done when deploying timed out, but the deploy log should be recorded
+	if err := client.Create(context.WithoutCancel(ctx), &configMap); err != nil {
+		log.Error(err, "failed to record deploy log")
+	}
+
+	if err := d.pruneDeployLogs(context.WithoutCancel(ctx), client, problemEnvironment); err != nil {
+		log.Error(err, "failed to prune deploy logs")
 	}
 
 	return err
 }
 
+// pruneDeployLogs deletes the deploy logs of ProblemEnvironment except the latest deployLogHistoryLimit ones.
+func (d *ContainerLabProblemEnvironmentDriver) pruneDeployLogs(
+	ctx context.Context,
+	c client.Client,
+	problemEnvironment *netconv1alpha1.ProblemEnvironment,
+) error {
+	if d.deployLogHistoryLimit <= 0 {
+		return nil
+	}
+
+	configMaps := corev1.ConfigMapList{}
+	if err := c.List(ctx, &configMaps,
+		client.InNamespace(problemEnvironment.Namespace),
+		client.MatchingLabels{netconv1alpha1.LabelDeployLogFor: problemEnvironment.Name},
+	); err != nil {
+		return err
+	}
+
+	util.SortDeployLogs(configMaps.Items)
+
+	for i := d.deployLogHistoryLimit; i < len(configMaps.Items); i++ {
+		if err := c.Delete(ctx, &configMaps.Items[i]); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// truncateMiddle truncates the middle of the output longer than limit, keeping both ends.
+// The head shows what was done, and the tail shows why it failed.
+// The result including the marker of truncation never exceeds limit.
+func truncateMiddle(output []byte, limit int) string {
+	if len(output) <= limit {
+		return string(output)
+	}
+
+	// the marker is counted with the largest possible number of bytes truncated
+	budget := limit - len(truncationMarker(len(output)))
+	if budget <= 0 {
+		// no room for the marker, just keep the head
+		return string(output[:alignRuneBackward(output, limit)])
+	}
+
+	// cut at line boundaries not to break log lines,
+	// or at rune boundaries not to break multi-byte characters without newlines
+	head := output[:alignRuneBackward(output, budget/2)]
+	if i := bytes.LastIndexByte(head, '\n'); i >= 0 {
+		head = head[:i+1]
+	}
+	tail := output[alignRuneForward(output, len(output)-(budget-budget/2)):]
+	if i := bytes.IndexByte(tail, '\n'); i >= 0 {
+		tail = tail[i+1:]
+	}
+
+	truncated := len(output) - len(head) - len(tail)
+	return string(head) + truncationMarker(truncated) + string(tail)
+}
+
+func truncationMarker(truncated int) string {
+	return fmt.Sprintf("... %d bytes truncated ...\n", truncated)
+}
+
+// alignRuneBackward returns the largest index not greater than i which doesn't split a multi-byte character.
+func alignRuneBackward(b []byte, i int) int {
+	for i > 0 && i < len(b) && !utf8.RuneStart(b[i]) {
+		i--
+	}
+	return i
+}
+
+// alignRuneForward returns the smallest index not less than i which doesn't split a multi-byte character.
+func alignRuneForward(b []byte, i int) int {
+	for i < len(b) && !utf8.RuneStart(b[i]) {
+		i++
+	}
+	return i
+}
+
 // Destroy implements ProblemEnvironmentDriver
 func (d *ContainerLabProblemEnvironmentDriver) Destroy(
 	ctx context.Context,
@@ -338,6 +490,194 @@ func (d *ContainerLabProblemEnvironmentDriver) Destroy(
 	return nil
 }
 
+// RestartContainer implements ProblemEnvironmentDriver
+func (d *ContainerLabProblemEnvironmentDriver) RestartContainer(
+	ctx context.Context,
+	client client.Client,
+	problemEnvironment netconv1alpha1.ProblemEnvironment,
+	name string,
+) error {
+	log := log.FromContext(ctx)
+
+	clabClient := containerlab.NewContainerLabClientFor(&problemEnvironment)
+
+	config, err := clabClient.LoadTopologyFile()
+	if err != nil {
+		return err
+	}
+
+	containerName := func(node string) string {
+		return fmt.Sprintf("clab-%s-%s", problemEnvironment.Name, node)
+	}
+
+	if err := d.dockerClient.ContainerStart(ctx, containerName(name), container.StartOptions{}); err != nil {
+		return fmt.Errorf("failed to start container: %w", err)
+	}
+
+	// the interfaces of the links are lost with the network namespace of the container
+	// exited, so the links connected to the node are created again
+	for _, link := range config.Topology.Links {
+		connected, betweenNodes := false, true
+		endpoints := make([]string, 0, len(link.Endpoints))
+		for _, endpoint := range link.Endpoints {
+			node, iface, _ := strings.Cut(endpoint, ":")
+			if _, ok := config.Topology.Nodes[node]; !ok {
+				betweenNodes = false
+			}
+			connected = connected || node == name
+			endpoints = append(endpoints, containerName(node)+":"+iface)
+		}
+
+		if !connected {
+			continue
+		}
+		if !betweenNodes || len(endpoints) != 2 {
+			log.Info("skipped to recreate the link not between nodes", "endpoints", link.Endpoints)
+			continue
+		}
+
+		if err := clabClient.CreateVeth(ctx, endpoints[0], endpoints[1]); err != nil {
+			return fmt.Errorf("failed to recreate link %v: %w", link.Endpoints, err)
+		}
+	}
+
+	return nil
+}
+
+// Hibernate implements ProblemEnvironmentDriver
+func (d *ContainerLabProblemEnvironmentDriver) Hibernate(
+	ctx context.Context,
+	client client.Client,
+	problemEnvironment netconv1alpha1.ProblemEnvironment,
+) error {
+	return d.forEachContainer(ctx, problemEnvironment, func(containerInfo dockerTypes.ContainerJSON) error {
+		if !containerInfo.State.Running || containerInfo.State.Paused {
+			return nil
+		}
+		return d.dockerClient.ContainerPause(ctx, containerInfo.ID)
+	})
+}
+
+// WakeUp implements ProblemEnvironmentDriver
+func (d *ContainerLabProblemEnvironmentDriver) WakeUp(
+	ctx context.Context,
+	client client.Client,
+	problemEnvironment netconv1alpha1.ProblemEnvironment,
+) error {
+	return d.forEachContainer(ctx, problemEnvironment, func(containerInfo dockerTypes.ContainerJSON) error {
+		if !containerInfo.State.Paused {
+			return nil
+		}
+		return d.dockerClient.ContainerUnpause(ctx, containerInfo.ID)
+	})
+}
+
+// forEachContainer calls fn with each container of ProblemEnvironment.
+func (d *ContainerLabProblemEnvironmentDriver) forEachContainer(
+	ctx context.Context,
+	problemEnvironment netconv1alpha1.ProblemEnvironment,
+	fn func(containerInfo dockerTypes.ContainerJSON) error,
+) error {
+	clabClient := containerlab.NewContainerLabClientFor(&problemEnvironment)
+
+	containers, err := clabClient.Inspect(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to inspect ContainerLab: %w", err)
+	}
+
+	for _, c := range containers {
+		containerInfo, err := d.dockerClient.ContainerInspect(ctx, c.ContainerID)
+		if err != nil {
+			return fmt.Errorf("failed to inspect container %s: %w", c.Name, err)
+		}
+		if err := fn(containerInfo); err != nil {
+			return fmt.Errorf("failed to handle container %s: %w", c.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// ListInstances implements ProblemEnvironmentDriver
+func (d *ContainerLabProblemEnvironmentDriver) ListInstances(ctx context.Context) ([]string, error) {
+	names := map[string]struct{}{}
+
+	entries, err := os.ReadDir(containerlab.BaseDirectoryPath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read directory: %w", err)
+	}
+	for _, entry := range entries {
+		// the directory also contains files of nclet itself, such as SSH host keys
+		if entry.IsDir() {
+			names[entry.Name()] = struct{}{}
+		}
+	}
+
+	containers, err := d.listContainers(ctx, filters.Arg("label", containerlab.LabelLabName))
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range containers {
+		names[c.Labels[containerlab.LabelLabName]] = struct{}{}
+	}
+
+	result := []string{}
+	for name := range names {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+// DestroyInstance implements ProblemEnvironmentDriver
+func (d *ContainerLabProblemEnvironmentDriver) DestroyInstance(ctx context.Context, name string) error {
+	log := log.FromContext(ctx)
+
+	problemEnvironment := netconv1alpha1.ProblemEnvironment{}
+	problemEnvironment.Name = name
+	clabClient := containerlab.NewContainerLabClientFor(&problemEnvironment)
+
+	// clab can clean up the links as well, but the topology file may be gone
+	if d.fileExists(clabClient.TopologyFilePath()) {
+		if err := clabClient.Destroy(ctx); err != nil {
+			log.Error(err, "failed to destroy ContainerLab, removing containers directly", "name", name)
+		}
+	}
+
+	containers, err := d.listContainers(ctx, filters.Arg("label", containerlab.LabelLabName+"="+name))
+	if err != nil {
+		return err
+	}
+	for _, c := range containers {
+		if err := d.dockerClient.ContainerRemove(ctx, c.ID, container.RemoveOptions{
+			RemoveVolumes: true,
+			Force:         true,
+		}); err != nil {
+			return fmt.Errorf("failed to remove container %s: %w", c.ID, err)
+		}
+	}
+
+	if _, err := d.delete(clabClient.WorkingDirectoryPath()); err != nil {
+		return fmt.Errorf("failed to delete directory for ProblemEnvironment: %w", err)
+	}
+
+	return nil
+}
+
+func (d *ContainerLabProblemEnvironmentDriver) listContainers(
+	ctx context.Context,
+	args ...filters.KeyValuePair,
+) ([]dockerTypes.Container, error) {
+	containers, err := d.dockerClient.ContainerList(ctx, container.ListOptions{
+		All:     true,
+		Filters: filters.NewArgs(args...),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list containers: %w", err)
+	}
+	return containers, nil
+}
+
 func (d *ContainerLabProblemEnvironmentDriver) ensureManagementNetwork(ctx context.Context) error {
 	name := "nc-mgmt"
 
@@ -0,0 +1,194 @@
+package drivers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	netconv1alpha1 "github.com/janog-netcon/netcon-problem-management-subsystem/api/v1alpha1"
+)
+
+func TestTruncateMiddle(t *testing.T) {
+	lines := func(n int) string {
+		b := strings.Builder{}
+		for i := range n {
+			fmt.Fprintf(&b, "line %03d\n", i)
+		}
+		return b.String()
+	}
+
+	tests := []struct {
+		name   string
+		output string
+		limit  int
+		want   string
+	}{
+		{
+			name:   "short output",
+			output: "hello\n",
+			limit:  6,
+			want:   "hello\n",
+		},
+		{
+			name:   "empty output",
+			output: "",
+			limit:  0,
+			want:   "",
+		},
+		{
+			name:   "cut at line boundaries",
+			output: lines(10),
+			limit:  64,
+			want:   "line 000\nline 001\n... 54 bytes truncated ...\nline 008\nline 009\n",
+		},
+		{
+			name:   "limit smaller than the marker",
+			output: lines(10),
+			limit:  12,
+			want:   "line 000\nlin",
+		},
+		{
+			name:   "limit smaller than the marker with multi-byte characters",
+			output: strings.Repeat("あ", 10),
+			limit:  8,
+			want:   "ああ",
+		},
+		{
+			name:   "zero limit",
+			output: "hello",
+			limit:  0,
+			want:   "",
+		},
+		{
+			name:   "multi-byte characters without newlines",
+			output: strings.Repeat("あ", 30),
+			limit:  45,
+			want:   "あああ... 72 bytes truncated ...\nあああ",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := truncateMiddle([]byte(tt.output), tt.limit)
+			if got != tt.want {
+				t.Errorf("unexpected output:\ngot  %q\nwant %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTruncateMiddleNeverExceedsLimit(t *testing.T) {
+	outputs := map[string]string{
+		"ascii lines":      strings.Repeat("0123456789abcdef\n", 20),
+		"ascii":            strings.Repeat("x", 300),
+		"multi-byte":       strings.Repeat("日本語", 40),
+		"multi-byte lines": strings.Repeat("ネットコン\n", 20),
+	}
+
+	for name, output := range outputs {
+		// odd limits are included to split the budget unevenly
+		for limit := 0; limit <= len(output); limit++ {
+			got := truncateMiddle([]byte(output), limit)
+			if len(got) > limit {
+				t.Fatalf("%s: output exceeds limit %d: %d bytes", name, limit, len(got))
+			}
+			if !utf8.ValidString(got) {
+				t.Fatalf("%s: multi-byte character is broken with limit %d: %q", name, limit, got)
+			}
+		}
+	}
+}
+
+func newTestDeployLog(name, problemEnvironmentName string, attempt int, createdAt time.Time) *corev1.ConfigMap {
+	configMap := &corev1.ConfigMap{}
+	configMap.Namespace = "default"
+	configMap.Name = name
+	configMap.CreationTimestamp = metav1.NewTime(createdAt)
+	configMap.Labels = map[string]string{
+		netconv1alpha1.LabelDeployLogFor:  problemEnvironmentName,
+		netconv1alpha1.LabelDeployAttempt: fmt.Sprint(attempt),
+	}
+	return configMap
+}
+
+func TestPruneDeployLogs(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	objects := []client.Object{
+		newTestDeployLog("deploy-tst-001-1", "tst-001", 1, now),
+		newTestDeployLog("deploy-tst-001-2", "tst-001", 2, now.Add(1*time.Minute)),
+		// the attempt is counted again after reset, so it is newer than the one above despite the smaller attempt
+		newTestDeployLog("deploy-tst-001-3", "tst-001", 1, now.Add(2*time.Minute)),
+		newTestDeployLog("deploy-tst-001-4", "tst-001", 1, now.Add(3*time.Minute)),
+		newTestDeployLog("deploy-tst-002-1", "tst-002", 1, now),
+	}
+
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build()
+
+	problemEnvironment := &netconv1alpha1.ProblemEnvironment{}
+	problemEnvironment.Namespace = "default"
+	problemEnvironment.Name = "tst-001"
+
+	d := &ContainerLabProblemEnvironmentDriver{deployLogHistoryLimit: 2}
+	if err := d.pruneDeployLogs(context.Background(), c, problemEnvironment); err != nil {
+		t.Fatal(err)
+	}
+
+	configMaps := corev1.ConfigMapList{}
+	if err := c.List(context.Background(), &configMaps); err != nil {
+		t.Fatal(err)
+	}
+
+	names := []string{}
+	for _, configMap := range configMaps.Items {
+		names = append(names, configMap.Name)
+	}
+	want := []string{"deploy-tst-001-3", "deploy-tst-001-4", "deploy-tst-002-1"}
+	if strings.Join(names, ",") != strings.Join(want, ",") {
+		t.Errorf("unexpected deploy logs: got %v, want %v", names, want)
+	}
+}
+
+func TestPruneDeployLogsWithoutLimit(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		newTestDeployLog("deploy-tst-001-1", "tst-001", 1, now),
+		newTestDeployLog("deploy-tst-001-2", "tst-001", 2, now.Add(time.Minute)),
+	).Build()
+
+	problemEnvironment := &netconv1alpha1.ProblemEnvironment{}
+	problemEnvironment.Namespace = "default"
+	problemEnvironment.Name = "tst-001"
+
+	d := &ContainerLabProblemEnvironmentDriver{deployLogHistoryLimit: 0}
+	if err := d.pruneDeployLogs(context.Background(), c, problemEnvironment); err != nil {
+		t.Fatal(err)
+	}
+
+	configMaps := corev1.ConfigMapList{}
+	if err := c.List(context.Background(), &configMaps); err != nil {
+		t.Fatal(err)
+	}
+	if len(configMaps.Items) != 2 {
+		t.Errorf("deploy logs should be kept without the limit: %d", len(configMaps.Items))
+	}
+}
@@ -0,0 +1,312 @@
+package drivers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	netconv1alpha1 "github.com/janog-netcon/netcon-problem-management-subsystem/api/v1alpha1"
+	"github.com/janog-netcon/netcon-problem-management-subsystem/pkg/containerlab"
+)
+
+// These are the annotation keys of ProblemEnvironment to script the behavior of FakeProblemEnvironmentDriver.
+// They are read on each call, so tests can change the behavior of ProblemEnvironment deployed.
+const (
+	// FakeDeployLatencyAnnotation is the annotation key to specify the period deploying takes, like "30s".
+	// Deploying fails with the error of ctx when it's done in the meantime.
+	FakeDeployLatencyAnnotation = "netcon.janog.gr.jp/fakeDeployLatency"
+
+	// FakeDeployFailureProbabilityAnnotation is the annotation key to specify the probability
+	// that deploying fails, from 0.0 to 1.0.
+	FakeDeployFailureProbabilityAnnotation = "netcon.janog.gr.jp/fakeDeployFailureProbability"
+
+	// FakeReadyAfterAnnotation is the annotation key to specify the period after deployed
+	// before the containers become ready, like "1m". It simulates slow-booting NOS.
+	FakeReadyAfterAnnotation = "netcon.janog.gr.jp/fakeReadyAfter"
+
+	// FakeNotReadyNodesAnnotation is the annotation key to specify the comma-separated nodes
+	// reported as not ready. It simulates nodes whose readiness flaps.
+	FakeNotReadyNodesAnnotation = "netcon.janog.gr.jp/fakeNotReadyNodes"
+
+	// FakeExitedNodesAnnotation is the annotation key to specify the comma-separated nodes
+	// whose containers exit. They run again once restarted, until the annotation is changed.
+	FakeExitedNodesAnnotation = "netcon.janog.gr.jp/fakeExitedNodes"
+)
+
+// fakeInstance is ProblemEnvironment deployed by FakeProblemEnvironmentDriver
+type fakeInstance struct {
+	deployedAt time.Time
+	nodes      []string
+	images     map[string]string
+	paused     bool
+
+	// exitedNodes is the value of FakeExitedNodesAnnotation when the containers exited
+	exitedNodes string
+	// restarted holds the nodes restarted after they exited
+	restarted map[string]bool
+}
+
+// FakeProblemEnvironmentDriver simulates ProblemEnvironments in memory without Docker or ContainerLab.
+// It reports a container per node in the topology, and its behavior is scripted by the annotations
+// of ProblemEnvironment. It's intended for end-to-end tests of nclet.
+type FakeProblemEnvironmentDriver struct {
+	mu        sync.Mutex
+	instances map[string]*fakeInstance
+
+	// deployLatency is the period deploying takes unless FakeDeployLatencyAnnotation is set
+	deployLatency time.Duration
+	// deployFailureProbability is the probability that deploying fails
+	// unless FakeDeployFailureProbabilityAnnotation is set
+	deployFailureProbability float64
+}
+
+var _ ProblemEnvironmentDriver = &FakeProblemEnvironmentDriver{}
+
+func NewFakeProblemEnvironmentDriver(
+	deployLatency time.Duration,
+	deployFailureProbability float64,
+) *FakeProblemEnvironmentDriver {
+	return &FakeProblemEnvironmentDriver{
+		instances:                map[string]*fakeInstance{},
+		deployLatency:            deployLatency,
+		deployFailureProbability: deployFailureProbability,
+	}
+}
+
+// Check implements ProblemEnvironmentDriver
+func (d *FakeProblemEnvironmentDriver) Check(
+	ctx context.Context,
+	client client.Client,
+	problemEnvironment netconv1alpha1.ProblemEnvironment,
+) (ProblemEnvironmentStatus, []netconv1alpha1.ContainerStatus) {
+	log := log.FromContext(ctx)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	instance, ok := d.instances[problemEnvironment.Name]
+	if !ok {
+		return StatusInit, nil
+	}
+
+	annotations := problemEnvironment.Annotations
+
+	readyAfter, err := parseFakeDuration(annotations, FakeReadyAfterAnnotation, 0)
+	if err != nil {
+		log.Error(err, "failed to parse annotation", "annotation", FakeReadyAfterAnnotation)
+	}
+	booted := time.Since(instance.deployedAt) >= readyAfter
+
+	notReadyNodes := parseFakeNodes(annotations, FakeNotReadyNodesAnnotation)
+
+	exitedNodes := annotations[FakeExitedNodesAnnotation]
+	if exitedNodes != instance.exitedNodes {
+		// the nodes listed newly exit again even if they were restarted
+		instance.exitedNodes = exitedNodes
+		instance.restarted = map[string]bool{}
+	}
+	exited := parseFakeNodes(annotations, FakeExitedNodesAnnotation)
+
+	containerStatuses := []netconv1alpha1.ContainerStatus{}
+	for i, node := range instance.nodes {
+		running := !exited[node] || instance.restarted[node]
+		containerStatuses = append(containerStatuses, netconv1alpha1.ContainerStatus{
+			Name:                node,
+			Image:               instance.images[node],
+			ContainerID:         fmt.Sprintf("fake-%s-%s", problemEnvironment.Name, node),
+			ContainerName:       fmt.Sprintf("clab-%s-%s", problemEnvironment.Name, node),
+			ManagementIPAddress: fmt.Sprintf("192.0.2.%d/24", i+1),
+			Running:             running,
+			// paused containers are running but not ready, as they don't respond to probes
+			Ready: running && booted && !notReadyNodes[node] && !instance.paused,
+		})
+	}
+
+	return StatusDeployed, containerStatuses
+}
+
+// Deploy implements ProblemEnvironmentDriver
+func (d *FakeProblemEnvironmentDriver) Deploy(
+	ctx context.Context,
+	client client.Client,
+	problemEnvironment netconv1alpha1.ProblemEnvironment,
+) error {
+	annotations := problemEnvironment.Annotations
+
+	deployLatency, err := parseFakeDuration(annotations, FakeDeployLatencyAnnotation, d.deployLatency)
+	if err != nil {
+		return err
+	}
+
+	failureProbability := d.deployFailureProbability
+	if value, ok := annotations[FakeDeployFailureProbabilityAnnotation]; ok {
+		failureProbability, err = strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("failed to parse annotation %s: %w", FakeDeployFailureProbabilityAnnotation, err)
+		}
+	}
+
+	// the topology is loaded in the same way as ContainerLabProblemEnvironmentDriver
+	// so that ProblemEnvironments referring missing ConfigMaps fail as well
+	topology, err := fetchFile(ctx, client, &problemEnvironment, &problemEnvironment.Spec.TopologyFile)
+	if err != nil {
+		return err
+	}
+
+	config := containerlab.Config{}
+	if err := yaml.Unmarshal(topology, &config); err != nil {
+		return fmt.Errorf("failed to parse topology file: %w", err)
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(deployLatency):
+	}
+
+	if rand.Float64() < failureProbability {
+		return errors.New("deploying failed as simulated")
+	}
+
+	instance := &fakeInstance{
+		deployedAt: time.Now(),
+		images:     map[string]string{},
+		restarted:  map[string]bool{},
+	}
+	for name, node := range config.Topology.Nodes {
+		instance.nodes = append(instance.nodes, name)
+		if node != nil {
+			instance.images[name] = node.Image
+		}
+	}
+	sort.Strings(instance.nodes)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.instances[problemEnvironment.Name] = instance
+	return nil
+}
+
+// Destroy implements ProblemEnvironmentDriver
+func (d *FakeProblemEnvironmentDriver) Destroy(
+	ctx context.Context,
+	client client.Client,
+	problemEnvironment netconv1alpha1.ProblemEnvironment,
+) error {
+	return d.DestroyInstance(ctx, problemEnvironment.Name)
+}
+
+// RestartContainer implements ProblemEnvironmentDriver
+func (d *FakeProblemEnvironmentDriver) RestartContainer(
+	ctx context.Context,
+	client client.Client,
+	problemEnvironment netconv1alpha1.ProblemEnvironment,
+	name string,
+) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	instance, ok := d.instances[problemEnvironment.Name]
+	if !ok {
+		return errors.New("ProblemEnvironment is not deployed")
+	}
+
+	instance.restarted[name] = true
+	return nil
+}
+
+// Hibernate implements ProblemEnvironmentDriver
+func (d *FakeProblemEnvironmentDriver) Hibernate(
+	ctx context.Context,
+	client client.Client,
+	problemEnvironment netconv1alpha1.ProblemEnvironment,
+) error {
+	return d.setPaused(problemEnvironment.Name, true)
+}
+
+// WakeUp implements ProblemEnvironmentDriver
+func (d *FakeProblemEnvironmentDriver) WakeUp(
+	ctx context.Context,
+	client client.Client,
+	problemEnvironment netconv1alpha1.ProblemEnvironment,
+) error {
+	return d.setPaused(problemEnvironment.Name, false)
+}
+
+// ListInstances implements ProblemEnvironmentDriver
+func (d *FakeProblemEnvironmentDriver) ListInstances(ctx context.Context) ([]string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	names := []string{}
+	for name := range d.instances {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// DestroyInstance implements ProblemEnvironmentDriver
+func (d *FakeProblemEnvironmentDriver) DestroyInstance(ctx context.Context, name string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delete(d.instances, name)
+	return nil
+}
+
+// Paused returns true if ProblemEnvironment is deployed and hibernated. It's intended for tests.
+func (d *FakeProblemEnvironmentDriver) Paused(name string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	instance, ok := d.instances[name]
+	return ok && instance.paused
+}
+
+func (d *FakeProblemEnvironmentDriver) setPaused(name string, paused bool) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	instance, ok := d.instances[name]
+	if !ok {
+		return errors.New("ProblemEnvironment is not deployed")
+	}
+
+	instance.paused = paused
+	return nil
+}
+
+func parseFakeDuration(annotations map[string]string, key string, defaultValue time.Duration) (time.Duration, error) {
+	value, ok := annotations[key]
+	if !ok {
+		return defaultValue, nil
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return defaultValue, fmt.Errorf("failed to parse annotation %s: %w", key, err)
+	}
+	return duration, nil
+}
+
+func parseFakeNodes(annotations map[string]string, key string) map[string]bool {
+	nodes := map[string]bool{}
+	for _, node := range strings.Split(annotations[key], ",") {
+		if node = strings.TrimSpace(node); node != "" {
+			nodes[node] = true
+		}
+	}
+	return nodes
+}
@@ -21,6 +21,13 @@ const (
 	StatusError ProblemEnvironmentStatus = "Error"
 )
 
+// Results of deploying ProblemEnvironment recorded as `result` in the deploy log
+const (
+	DeployResultSucceeded = "Succeeded"
+	DeployResultFailed    = "Failed"
+	DeployResultTimedOut  = "TimedOut"
+)
+
 type ProblemEnvironmentDriver interface {
 	// Check whether ProblemEnvironment is deployed or not and return ContainerStatus
 	// []ContainerDetailStatus should be nil if ProblemEnvironment is not deployed successfully
@@ -31,4 +38,20 @@ type ProblemEnvironmentDriver interface {
 
 	// Destroy ProblemEnvironment
 	Destroy(ctx context.Context, reader client.Client, problemEnvironment netconv1alpha1.ProblemEnvironment) error
+
+	// RestartContainer starts the exited container of ProblemEnvironment again
+	RestartContainer(ctx context.Context, reader client.Client, problemEnvironment netconv1alpha1.ProblemEnvironment, name string) error
+
+	// Hibernate pauses all containers of ProblemEnvironment
+	Hibernate(ctx context.Context, reader client.Client, problemEnvironment netconv1alpha1.ProblemEnvironment) error
+
+	// WakeUp resumes all containers of ProblemEnvironment paused by Hibernate
+	WakeUp(ctx context.Context, reader client.Client, problemEnvironment netconv1alpha1.ProblemEnvironment) error
+
+	// ListInstances returns the names of ProblemEnvironments whose files or containers exist on the worker
+	ListInstances(ctx context.Context) ([]string, error)
+
+	// DestroyInstance destroys the files and containers of ProblemEnvironment by its name.
+	// It's used for the instances left without ProblemEnvironment.
+	DestroyInstance(ctx context.Context, name string) error
 }
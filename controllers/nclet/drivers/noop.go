@@ -42,3 +42,41 @@ func (*NoopProblemEnvironmentDriver) Destroy(
 ) error {
 	return nil
 }
+
+// RestartContainer implements ProblemEnvironmentDriver
+func (*NoopProblemEnvironmentDriver) RestartContainer(
+	ctx context.Context,
+	client client.Client,
+	problemEnvironment netconv1alpha1.ProblemEnvironment,
+	name string,
+) error {
+	return nil
+}
+
+// Hibernate implements ProblemEnvironmentDriver
+func (*NoopProblemEnvironmentDriver) Hibernate(
+	ctx context.Context,
+	client client.Client,
+	problemEnvironment netconv1alpha1.ProblemEnvironment,
+) error {
+	return nil
+}
+
+// WakeUp implements ProblemEnvironmentDriver
+func (*NoopProblemEnvironmentDriver) WakeUp(
+	ctx context.Context,
+	client client.Client,
+	problemEnvironment netconv1alpha1.ProblemEnvironment,
+) error {
+	return nil
+}
+
+// ListInstances implements ProblemEnvironmentDriver
+func (*NoopProblemEnvironmentDriver) ListInstances(ctx context.Context) ([]string, error) {
+	return nil, nil
+}
+
+// DestroyInstance implements ProblemEnvironmentDriver
+func (*NoopProblemEnvironmentDriver) DestroyInstance(ctx context.Context, name string) error {
+	return nil
+}
@@ -0,0 +1,387 @@
+package drivers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	dockerTypes "github.com/docker/docker/api/types"
+	dockerClient "github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/google/shlex"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// These are the label keys of the nodes in ContainerLab to declare the readiness probe.
+// ContainerLab sets the labels of the nodes to their containers, so nclet reads them from the containers.
+const (
+	// readinessProbeKey is the label key to specify the type of the readiness probe.
+	// The possible values are "tcp", "ssh" and "exec".
+	// With "tcp", the node is ready when the port is open.
+	// With "ssh", the node is ready when SSH login succeeds.
+	// With "exec", the node is ready when the command exits with 0 in the container.
+	readinessProbeKey = "netcon.janog.gr.jp/readinessProbe"
+
+	// readinessProbePortKey is the label key to specify the port for "tcp" and "ssh".
+	// It's required for "tcp". For "ssh", the value of sshPortKey or defaultSSHPort is used by default.
+	readinessProbePortKey = "netcon.janog.gr.jp/readinessProbePort"
+
+	// readinessProbeCommandKey is the label key to specify the command for "exec".
+	readinessProbeCommandKey = "netcon.janog.gr.jp/readinessProbeCommand"
+
+	// readinessProbeOutputPatternKey is the label key to specify the regular expression
+	// which the output of the command for "exec" must match.
+	readinessProbeOutputPatternKey = "netcon.janog.gr.jp/readinessProbeOutputPattern"
+
+	// readinessProbeInitialDelaySecondsKey is the label key to specify the period to wait
+	// after the container is started before the first probe. The default value is 0.
+	readinessProbeInitialDelaySecondsKey = "netcon.janog.gr.jp/readinessProbeInitialDelaySeconds"
+
+	// readinessProbePeriodSecondsKey is the label key to specify the interval of the probe.
+	// The default value is 10.
+	readinessProbePeriodSecondsKey = "netcon.janog.gr.jp/readinessProbePeriodSeconds"
+
+	// readinessProbeTimeoutSecondsKey is the label key to specify the timeout of each probe.
+	// The default value is 5.
+	readinessProbeTimeoutSecondsKey = "netcon.janog.gr.jp/readinessProbeTimeoutSeconds"
+
+	// readinessProbeSuccessThresholdKey is the label key to specify the number of consecutive
+	// successes for the node to be ready. The default value is 1.
+	readinessProbeSuccessThresholdKey = "netcon.janog.gr.jp/readinessProbeSuccessThreshold"
+
+	// readinessProbeFailureThresholdKey is the label key to specify the number of consecutive
+	// failures for the node ready once to be not ready. The default value is 3.
+	readinessProbeFailureThresholdKey = "netcon.janog.gr.jp/readinessProbeFailureThreshold"
+
+	// These are the same as the labels used by access-helper to connect to the node via SSH.
+	sshUsernameKey = "netcon.janog.gr.jp/sshUsername"
+	sshPasswordKey = "netcon.janog.gr.jp/sshPassword"
+	sshPortKey     = "netcon.janog.gr.jp/sshPort"
+
+	defaultSSHUsername = "clab"
+	defaultSSHPassword = "clab@123"
+	defaultSSHPort     = 22
+)
+
+const (
+	readinessProbeTCP  = "tcp"
+	readinessProbeSSH  = "ssh"
+	readinessProbeExec = "exec"
+)
+
+// probeStateExpiration is the period after which the state of containers not probed is forgotten
+const probeStateExpiration = 10 * time.Minute
+
+type readinessProbe struct {
+	probeType string
+
+	port          int
+	command       []string
+	outputPattern *regexp.Regexp
+	sshUsername   string
+	sshPassword   string
+
+	initialDelay     time.Duration
+	period           time.Duration
+	timeout          time.Duration
+	successThreshold int
+	failureThreshold int
+}
+
+// parseReadinessProbe parses the readiness probe declared by the labels.
+// It returns nil if no readiness probe is declared.
+func parseReadinessProbe(labels map[string]string) (*readinessProbe, error) {
+	probeType, ok := labels[readinessProbeKey]
+	if !ok {
+		return nil, nil
+	}
+
+	probe := &readinessProbe{probeType: probeType}
+
+	intLabel := func(key string, defaultValue int) (int, error) {
+		v, ok := labels[key]
+		if !ok {
+			return defaultValue, nil
+		}
+		i, err := strconv.Atoi(v)
+		if err != nil || i < 0 {
+			return 0, fmt.Errorf("invalid value of %s: %s", key, v)
+		}
+		return i, nil
+	}
+
+	var err error
+	switch probeType {
+	case readinessProbeTCP:
+		if _, ok := labels[readinessProbePortKey]; !ok {
+			return nil, fmt.Errorf("%s is required for %s probe", readinessProbePortKey, probeType)
+		}
+		if probe.port, err = intLabel(readinessProbePortKey, 0); err != nil {
+			return nil, err
+		}
+	case readinessProbeSSH:
+		port, err := intLabel(sshPortKey, defaultSSHPort)
+		if err != nil {
+			return nil, err
+		}
+		if probe.port, err = intLabel(readinessProbePortKey, port); err != nil {
+			return nil, err
+		}
+		probe.sshUsername, probe.sshPassword = defaultSSHUsername, defaultSSHPassword
+		if v, ok := labels[sshUsernameKey]; ok {
+			probe.sshUsername = v
+		}
+		if v, ok := labels[sshPasswordKey]; ok {
+			probe.sshPassword = v
+		}
+	case readinessProbeExec:
+		command, ok := labels[readinessProbeCommandKey]
+		if !ok {
+			return nil, fmt.Errorf("%s is required for %s probe", readinessProbeCommandKey, probeType)
+		}
+		if probe.command, err = shlex.Split(command); err != nil || len(probe.command) == 0 {
+			return nil, fmt.Errorf("invalid value of %s: %s", readinessProbeCommandKey, command)
+		}
+		if pattern, ok := labels[readinessProbeOutputPatternKey]; ok {
+			if probe.outputPattern, err = regexp.Compile(pattern); err != nil {
+				return nil, fmt.Errorf("invalid value of %s: %w", readinessProbeOutputPatternKey, err)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unknown readiness probe: %s", probeType)
+	}
+
+	initialDelaySeconds, err := intLabel(readinessProbeInitialDelaySecondsKey, 0)
+	if err != nil {
+		return nil, err
+	}
+	periodSeconds, err := intLabel(readinessProbePeriodSecondsKey, 10)
+	if err != nil {
+		return nil, err
+	}
+	timeoutSeconds, err := intLabel(readinessProbeTimeoutSecondsKey, 5)
+	if err != nil {
+		return nil, err
+	}
+	if probe.successThreshold, err = intLabel(readinessProbeSuccessThresholdKey, 1); err != nil {
+		return nil, err
+	}
+	if probe.failureThreshold, err = intLabel(readinessProbeFailureThresholdKey, 3); err != nil {
+		return nil, err
+	}
+
+	probe.initialDelay = time.Duration(initialDelaySeconds) * time.Second
+	probe.period = time.Duration(periodSeconds) * time.Second
+	probe.timeout = time.Duration(timeoutSeconds) * time.Second
+	probe.successThreshold = max(probe.successThreshold, 1)
+	probe.failureThreshold = max(probe.failureThreshold, 1)
+
+	return probe, nil
+}
+
+// probeState is the result of the readiness probe of a container.
+type probeState struct {
+	ready     bool
+	successes int
+	failures  int
+	probedAt  time.Time
+}
+
+// readinessProber runs the readiness probes of containers and remembers the results,
+// so that the probes are run at the interval and the thresholds are applied.
+// It's safe to probe different containers concurrently.
+type readinessProber struct {
+	mu     sync.Mutex
+	states map[string]*probeState
+
+	dockerClient dockerClient.APIClient
+
+	// now and run are replaced in tests
+	now func() time.Time
+	run func(ctx context.Context, probe *readinessProbe, containerID, ipv4Address string) error
+}
+
+func newReadinessProber(dockerClient dockerClient.APIClient) *readinessProber {
+	p := &readinessProber{
+		states:       map[string]*probeState{},
+		dockerClient: dockerClient,
+		now:          time.Now,
+	}
+	p.run = p.runProbe
+	return p
+}
+
+// ready returns whether the container is ready according to its readiness probe.
+// Containers without the readiness probe are always ready.
+func (p *readinessProber) ready(
+	ctx context.Context,
+	containerInfo dockerTypes.ContainerJSON,
+	ipv4Address string,
+) (bool, error) {
+	if containerInfo.Config == nil || containerInfo.State == nil {
+		return false, nil
+	}
+
+	probe, err := parseReadinessProbe(containerInfo.Config.Labels)
+	if err != nil {
+		return false, err
+	}
+	if probe == nil {
+		return true, nil
+	}
+
+	startedAt, err := time.Parse(time.RFC3339Nano, containerInfo.State.StartedAt)
+	if err == nil && p.now().Sub(startedAt) < probe.initialDelay {
+		return false, nil
+	}
+
+	state := p.state(containerInfo.ID)
+
+	p.mu.Lock()
+	if p.now().Sub(state.probedAt) < probe.period {
+		defer p.mu.Unlock()
+		return state.ready, nil
+	}
+	p.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, probe.timeout)
+	defer cancel()
+
+	probeErr := p.run(ctx, probe, containerInfo.ID, ipv4Address)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	state.probedAt = p.now()
+	if probeErr == nil {
+		state.successes++
+		state.failures = 0
+		if state.successes >= probe.successThreshold {
+			state.ready = true
+		}
+	} else {
+		state.failures++
+		state.successes = 0
+		if state.failures >= probe.failureThreshold {
+			state.ready = false
+		}
+	}
+	return state.ready, nil
+}
+
+// state returns the state of the container, forgetting the ones not probed for a while.
+func (p *readinessProber) state(containerID string) *probeState {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for id, state := range p.states {
+		// states not probed yet are being probed by another goroutine
+		if !state.probedAt.IsZero() && p.now().Sub(state.probedAt) > probeStateExpiration {
+			delete(p.states, id)
+		}
+	}
+
+	state, ok := p.states[containerID]
+	if !ok {
+		state = &probeState{}
+		p.states[containerID] = state
+	}
+	return state
+}
+
+func (p *readinessProber) runProbe(ctx context.Context, probe *readinessProbe, containerID, ipv4Address string) error {
+	// ipv4Address is in the CIDR notation
+	host, _, _ := strings.Cut(ipv4Address, "/")
+	address := net.JoinHostPort(host, strconv.Itoa(probe.port))
+
+	switch probe.probeType {
+	case readinessProbeTCP:
+		conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", address)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	case readinessProbeSSH:
+		return p.runSSH(ctx, probe, address)
+	case readinessProbeExec:
+		return p.runExec(ctx, probe, containerID)
+	}
+	return fmt.Errorf("unknown readiness probe: %s", probe.probeType)
+}
+
+func (p *readinessProber) runSSH(ctx context.Context, probe *readinessProbe, address string) error {
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", address)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	config := &gossh.ClientConfig{
+		User: probe.sshUsername,
+		Auth: []gossh.AuthMethod{
+			gossh.Password(probe.sshPassword),
+			gossh.KeyboardInteractive(func(user, instruction string, questions []string, echos []bool) ([]string, error) {
+				answers := make([]string, len(questions))
+				for i := range answers {
+					answers[i] = probe.sshPassword
+				}
+				return answers, nil
+			}),
+		},
+		HostKeyCallback: gossh.InsecureIgnoreHostKey(),
+	}
+
+	sshConn, chans, reqs, err := gossh.NewClientConn(conn, address, config)
+	if err != nil {
+		return err
+	}
+	return gossh.NewClient(sshConn, chans, reqs).Close()
+}
+
+func (p *readinessProber) runExec(ctx context.Context, probe *readinessProbe, containerID string) error {
+	exec, err := p.dockerClient.ContainerExecCreate(ctx, containerID, dockerTypes.ExecConfig{
+		Cmd:          probe.command,
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		return err
+	}
+
+	resp, err := p.dockerClient.ContainerExecAttach(ctx, exec.ID, dockerTypes.ExecStartCheck{})
+	if err != nil {
+		return err
+	}
+	defer resp.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		resp.Conn.SetDeadline(deadline)
+	}
+
+	output := bytes.Buffer{}
+	if _, err := stdcopy.StdCopy(&output, &output, resp.Reader); err != nil {
+		return err
+	}
+
+	inspect, err := p.dockerClient.ContainerExecInspect(ctx, exec.ID)
+	if err != nil {
+		return err
+	}
+	if inspect.ExitCode != 0 {
+		return fmt.Errorf("command exited with %d", inspect.ExitCode)
+	}
+	if probe.outputPattern != nil && !probe.outputPattern.Match(output.Bytes()) {
+		return fmt.Errorf("output doesn't match %s", probe.outputPattern)
+	}
+	return nil
+}
@@ -0,0 +1,316 @@
+package drivers
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	dockerTypes "github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+)
+
+func TestParseReadinessProbe(t *testing.T) {
+	tests := []struct {
+		name    string
+		labels  map[string]string
+		want    *readinessProbe
+		wantErr bool
+	}{
+		{
+			name:   "no probe",
+			labels: map[string]string{},
+			want:   nil,
+		},
+		{
+			name: "tcp with defaults",
+			labels: map[string]string{
+				readinessProbeKey:     "tcp",
+				readinessProbePortKey: "830",
+			},
+			want: &readinessProbe{
+				probeType:        readinessProbeTCP,
+				port:             830,
+				period:           10 * time.Second,
+				timeout:          5 * time.Second,
+				successThreshold: 1,
+				failureThreshold: 3,
+			},
+		},
+		{
+			name:    "tcp without port",
+			labels:  map[string]string{readinessProbeKey: "tcp"},
+			wantErr: true,
+		},
+		{
+			name: "ssh with sshPort and credentials",
+			labels: map[string]string{
+				readinessProbeKey: "ssh",
+				sshPortKey:        "2022",
+				sshUsernameKey:    "admin",
+				sshPasswordKey:    "admin",
+			},
+			want: &readinessProbe{
+				probeType:        readinessProbeSSH,
+				port:             2022,
+				sshUsername:      "admin",
+				sshPassword:      "admin",
+				period:           10 * time.Second,
+				timeout:          5 * time.Second,
+				successThreshold: 1,
+				failureThreshold: 3,
+			},
+		},
+		{
+			name: "ssh with the default credentials and the probe port",
+			labels: map[string]string{
+				readinessProbeKey:     "ssh",
+				sshPortKey:            "2022",
+				readinessProbePortKey: "22",
+			},
+			want: &readinessProbe{
+				probeType:        readinessProbeSSH,
+				port:             22,
+				sshUsername:      defaultSSHUsername,
+				sshPassword:      defaultSSHPassword,
+				period:           10 * time.Second,
+				timeout:          5 * time.Second,
+				successThreshold: 1,
+				failureThreshold: 3,
+			},
+		},
+		{
+			name: "exec with timings and thresholds",
+			labels: map[string]string{
+				readinessProbeKey:                    "exec",
+				readinessProbeCommandKey:             `sr_cli -d "info from state system app-management"`,
+				readinessProbeInitialDelaySecondsKey: "30",
+				readinessProbePeriodSecondsKey:       "5",
+				readinessProbeTimeoutSecondsKey:      "3",
+				readinessProbeSuccessThresholdKey:    "2",
+				readinessProbeFailureThresholdKey:    "0",
+			},
+			want: &readinessProbe{
+				probeType:        readinessProbeExec,
+				command:          []string{"sr_cli", "-d", "info from state system app-management"},
+				initialDelay:     30 * time.Second,
+				period:           5 * time.Second,
+				timeout:          3 * time.Second,
+				successThreshold: 2,
+				failureThreshold: 1,
+			},
+		},
+		{
+			name:    "exec without command",
+			labels:  map[string]string{readinessProbeKey: "exec"},
+			wantErr: true,
+		},
+		{
+			name: "exec with invalid pattern",
+			labels: map[string]string{
+				readinessProbeKey:              "exec",
+				readinessProbeCommandKey:       "true",
+				readinessProbeOutputPatternKey: "(",
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative period",
+			labels: map[string]string{
+				readinessProbeKey:              "tcp",
+				readinessProbePortKey:          "22",
+				readinessProbePeriodSecondsKey: "-1",
+			},
+			wantErr: true,
+		},
+		{
+			name:    "unknown probe",
+			labels:  map[string]string{readinessProbeKey: "http"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseReadinessProbe(tt.labels)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("unexpected probe: got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseReadinessProbeOutputPattern(t *testing.T) {
+	probe, err := parseReadinessProbe(map[string]string{
+		readinessProbeKey:              "exec",
+		readinessProbeCommandKey:       "show version",
+		readinessProbeOutputPatternKey: "^Version",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if probe.outputPattern == nil || !probe.outputPattern.MatchString("Version 1.0") {
+		t.Errorf("unexpected output pattern: %v", probe.outputPattern)
+	}
+}
+
+// fakeProbeClock is the clock and the probe results injected to readinessProber.
+type fakeProbeClock struct {
+	now     time.Time
+	results []error
+	runs    int
+}
+
+func newTestReadinessProber(clock *fakeProbeClock) *readinessProber {
+	p := newReadinessProber(nil)
+	p.now = func() time.Time { return clock.now }
+	p.run = func(context.Context, *readinessProbe, string, string) error {
+		err := clock.results[clock.runs]
+		clock.runs++
+		return err
+	}
+	return p
+}
+
+func newTestContainerInfo(startedAt time.Time, labels map[string]string) dockerTypes.ContainerJSON {
+	return dockerTypes.ContainerJSON{
+		ContainerJSONBase: &dockerTypes.ContainerJSONBase{
+			ID: "container-001",
+			State: &dockerTypes.ContainerState{
+				Running:   true,
+				StartedAt: startedAt.Format(time.RFC3339Nano),
+			},
+		},
+		Config: &container.Config{Labels: labels},
+	}
+}
+
+func TestReadinessProberReady(t *testing.T) {
+	errProbe := errors.New("probe failed")
+	startedAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	labels := map[string]string{
+		readinessProbeKey:                    "tcp",
+		readinessProbePortKey:                "22",
+		readinessProbeInitialDelaySecondsKey: "30",
+		readinessProbePeriodSecondsKey:       "10",
+		readinessProbeSuccessThresholdKey:    "2",
+		readinessProbeFailureThresholdKey:    "2",
+	}
+
+	type step struct {
+		// elapsed is the period since the container started
+		elapsed time.Duration
+		want    bool
+		// probed is true if the probe should be run in the step
+		probed bool
+	}
+
+	tests := []struct {
+		name    string
+		results []error
+		steps   []step
+	}{
+		{
+			name:    "not ready during the initial delay",
+			results: []error{},
+			steps: []step{
+				{elapsed: 0, want: false},
+				{elapsed: 29 * time.Second, want: false},
+			},
+		},
+		{
+			name:    "ready after the success threshold",
+			results: []error{nil, nil},
+			steps: []step{
+				{elapsed: 30 * time.Second, want: false, probed: true},
+				// the result is cached within the period
+				{elapsed: 35 * time.Second, want: false},
+				{elapsed: 40 * time.Second, want: true, probed: true},
+			},
+		},
+		{
+			name:    "failures reset the successes",
+			results: []error{nil, errProbe, nil, nil},
+			steps: []step{
+				{elapsed: 30 * time.Second, want: false, probed: true},
+				{elapsed: 40 * time.Second, want: false, probed: true},
+				{elapsed: 50 * time.Second, want: false, probed: true},
+				{elapsed: 60 * time.Second, want: true, probed: true},
+			},
+		},
+		{
+			name:    "not ready after the failure threshold",
+			results: []error{nil, nil, errProbe, nil, errProbe, errProbe},
+			steps: []step{
+				{elapsed: 30 * time.Second, want: false, probed: true},
+				{elapsed: 40 * time.Second, want: true, probed: true},
+				// a single failure is tolerated
+				{elapsed: 50 * time.Second, want: true, probed: true},
+				{elapsed: 60 * time.Second, want: true, probed: true},
+				{elapsed: 70 * time.Second, want: true, probed: true},
+				{elapsed: 80 * time.Second, want: false, probed: true},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := &fakeProbeClock{results: tt.results}
+			p := newTestReadinessProber(clock)
+			containerInfo := newTestContainerInfo(startedAt, labels)
+
+			for i, step := range tt.steps {
+				clock.now = startedAt.Add(step.elapsed)
+				runs := clock.runs
+
+				ready, err := p.ready(context.Background(), containerInfo, "192.0.2.1/24")
+				if err != nil {
+					t.Fatalf("step %d: unexpected error: %v", i, err)
+				}
+				if ready != step.want {
+					t.Errorf("step %d: unexpected readiness: got %v, want %v", i, ready, step.want)
+				}
+				if probed := clock.runs > runs; probed != step.probed {
+					t.Errorf("step %d: unexpected probe: got %v, want %v", i, probed, step.probed)
+				}
+			}
+		})
+	}
+}
+
+func TestReadinessProberWithoutProbe(t *testing.T) {
+	p := newTestReadinessProber(&fakeProbeClock{})
+
+	ready, err := p.ready(context.Background(), newTestContainerInfo(time.Now(), nil), "192.0.2.1/24")
+	if err != nil || !ready {
+		t.Errorf("containers without the probe should be ready: %v, %v", ready, err)
+	}
+}
+
+func TestReadinessProberForgetsStates(t *testing.T) {
+	clock := &fakeProbeClock{now: time.Now(), results: []error{nil}}
+	p := newTestReadinessProber(clock)
+
+	labels := map[string]string{
+		readinessProbeKey:     "tcp",
+		readinessProbePortKey: "22",
+	}
+	if _, err := p.ready(context.Background(), newTestContainerInfo(clock.now, labels), "192.0.2.1/24"); err != nil {
+		t.Fatal(err)
+	}
+
+	clock.now = clock.now.Add(probeStateExpiration + time.Second)
+	p.state("container-002")
+
+	if _, ok := p.states["container-001"]; ok {
+		t.Errorf("the state of container-001 should be forgotten")
+	}
+	if _, ok := p.states["container-002"]; !ok {
+		t.Errorf("the state of container-002 should be kept while it's being probed")
+	}
+}
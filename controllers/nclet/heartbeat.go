@@ -4,10 +4,15 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"sort"
 	"strconv"
 	"time"
 
+	dockerTypes "github.com/docker/docker/api/types"
+	dockerClient "github.com/docker/docker/client"
 	coordv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 
@@ -24,6 +29,10 @@ import (
 const (
 	CPU_USED_HISTORY_SIZE = 20
 	MEM_USED_HISTORY_SIZE = 20
+
+	// MAX_REPORTED_IMAGES is the maximum number of images reported in WorkerStatus.
+	// Larger images are preferred as they matter more for the scheduler.
+	MAX_REPORTED_IMAGES = 50
 )
 
 type HeartbeatAgent struct {
@@ -41,11 +50,19 @@ type HeartbeatAgent struct {
 	heartbeatTicker    *time.Ticker
 	statusUpdateTicker *time.Ticker
 
+	// reserved is the amount of resources reserved for the system, such as nclet itself
+	reserved corev1.ResourceList
+	// allocatable is the amount of resources which can be requested by ProblemEnvironments
+	allocatable corev1.ResourceList
+
+	// dockerClient is used to report images present on the Worker. It can be nil.
+	dockerClient dockerClient.APIClient
+
 	cpuUsedHistory [CPU_USED_HISTORY_SIZE]float64
 	memUsedHistory [MEM_USED_HISTORY_SIZE]float64
 }
 
-func NewHeartbeatAgent(client client.Client, workerName string, workerClass string, externalIPaddr string, externalPort uint16, heartbeatInterval time.Duration, statusUpdateInterval time.Duration) *HeartbeatAgent {
+func NewHeartbeatAgent(client client.Client, workerName string, workerClass string, externalIPaddr string, externalPort uint16, heartbeatInterval time.Duration, statusUpdateInterval time.Duration, reserved corev1.ResourceList, dockerClient dockerClient.APIClient) *HeartbeatAgent {
 	return &HeartbeatAgent{
 		Client:             client,
 		workerName:         workerName,
@@ -54,6 +71,8 @@ func NewHeartbeatAgent(client client.Client, workerName string, workerClass stri
 		externalPort:       externalPort,
 		heartbeatTicker:    time.NewTicker(heartbeatInterval),
 		statusUpdateTicker: time.NewTicker(statusUpdateInterval),
+		reserved:           reserved,
+		dockerClient:       dockerClient,
 	}
 }
 
@@ -80,8 +99,8 @@ func (a *HeartbeatAgent) Start(ctx context.Context) error {
 		if worker.Labels == nil {
 			worker.Labels = map[string]string{}
 		}
-		worker.Labels["netcon.janog.gr.jp/workerName"] = a.workerName
-		worker.Labels["netcon.janog.gr.jp/workerClass"] = a.workerClass
+		worker.Labels[netconv1alpha1.LabelWorkerName] = a.workerName
+		worker.Labels[netconv1alpha1.LabelWorkerClass] = a.workerClass
 		return nil
 	}); err != nil {
 		return err
@@ -159,6 +178,14 @@ func (a *HeartbeatAgent) Start(ctx context.Context) error {
 				ExternalPort:      a.externalPort,
 				CPUUsedPercent:    strconv.FormatFloat(cpuUsed, 'f', -1, 64),
 				MemoryUsedPercent: strconv.FormatFloat(memUsed, 'f', -1, 64),
+				Allocatable:       a.allocatable,
+			}
+
+			images, err := a.getImages(ctx)
+			if err != nil {
+				log.Error(err, "failed to list images")
+			} else {
+				worker.Status.Images = images
 			}
 
 			if err := a.Status().Update(ctx, &worker); err != nil {
@@ -176,6 +203,12 @@ func (a *HeartbeatAgent) initMetricsCollector(ctx context.Context) error {
 		return fmt.Errorf("failed to collect metrics: %w", err)
 	}
 
+	allocatable, err := a.getAllocatable(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get allocatable resources: %w", err)
+	}
+	a.allocatable = allocatable
+
 	for i := range a.cpuUsedHistory {
 		a.cpuUsedHistory[i] = 0
 	}
@@ -187,6 +220,71 @@ func (a *HeartbeatAgent) initMetricsCollector(ctx context.Context) error {
 	return nil
 }
 
+// getAllocatable returns the capacity of the Worker minus the reserved resources.
+func (a *HeartbeatAgent) getAllocatable(ctx context.Context) (corev1.ResourceList, error) {
+	cores, err := cpu.CountsWithContext(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+
+	memInfo, err := mem.VirtualMemoryWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	allocatable := corev1.ResourceList{
+		corev1.ResourceCPU:    *resource.NewQuantity(int64(cores), resource.DecimalSI),
+		corev1.ResourceMemory: *resource.NewQuantity(int64(memInfo.Total), resource.BinarySI),
+	}
+
+	for name, capacity := range allocatable {
+		if reserved, ok := a.reserved[name]; ok {
+			capacity.Sub(reserved)
+			if capacity.Sign() < 0 {
+				capacity.Set(0)
+			}
+			allocatable[name] = capacity
+		}
+	}
+
+	return allocatable, nil
+}
+
+// getImages returns the images present on the Worker, sorted by size in descending order.
+func (a *HeartbeatAgent) getImages(ctx context.Context) ([]corev1.ContainerImage, error) {
+	if a.dockerClient == nil {
+		return nil, nil
+	}
+
+	summaries, err := a.dockerClient.ImageList(ctx, dockerTypes.ImageListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].Size > summaries[j].Size
+	})
+
+	images := []corev1.ContainerImage{}
+	for _, summary := range summaries {
+		names := append([]string{}, summary.RepoTags...)
+		names = append(names, summary.RepoDigests...)
+		if len(names) == 0 {
+			// dangling images are never used by ProblemEnvironments
+			continue
+		}
+
+		images = append(images, corev1.ContainerImage{
+			Names:     names,
+			SizeBytes: summary.Size,
+		})
+		if len(images) >= MAX_REPORTED_IMAGES {
+			break
+		}
+	}
+	return images, nil
+}
+
 func (a *HeartbeatAgent) collectMetrics(ctx context.Context) error {
 	log := log.FromContext(ctx)
 
@@ -6,6 +6,8 @@ func RegisterMetrics(reg prometheus.Registerer) {
 	reg.MustRegister(sshAuthTotal)
 	reg.MustRegister(sshSessionDuration)
 	reg.MustRegister(sshSessionsInFlight)
+	reg.MustRegister(orphans)
+	reg.MustRegister(orphansDestroyedTotal)
 }
 
 var (
@@ -42,4 +44,22 @@ var (
 			Help:      "Current number of in-flight SSH sessions",
 		},
 	)
+
+	orphans = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "netcon",
+			Subsystem: "nclet",
+			Name:      "orphans",
+			Help:      "Current number of instances left on the worker without ProblemEnvironment",
+		},
+	)
+
+	orphansDestroyedTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "netcon",
+			Subsystem: "nclet",
+			Name:      "orphans_destroyed_total",
+			Help:      "Total number of instances destroyed as orphans",
+		},
+	)
 )
@@ -0,0 +1,167 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	netconv1alpha1 "github.com/janog-netcon/netcon-problem-management-subsystem/api/v1alpha1"
+	"github.com/janog-netcon/netcon-problem-management-subsystem/controllers/nclet/drivers"
+)
+
+// OrphanCollector finds instances left on the worker without ProblemEnvironment, such as
+// the ones of ProblemEnvironments force-deleted or nclet crashed while destroying.
+// Orphans are reported with metrics and events on Worker, and destroyed after gracePeriod.
+type OrphanCollector struct {
+	client.Client
+
+	recorder record.EventRecorder
+
+	// workerName is the name of Worker that nclet runs on
+	workerName string
+
+	interval time.Duration
+
+	// gracePeriod is the period to wait before destroying orphans. If it's 0, orphans are only reported.
+	gracePeriod time.Duration
+
+	driver drivers.ProblemEnvironmentDriver
+
+	// foundAt holds the time when each orphan was found first
+	foundAt map[string]time.Time
+
+	// now is replaced in tests
+	now func() time.Time
+}
+
+func NewOrphanCollector(
+	client client.Client,
+	recorder record.EventRecorder,
+	workerName string,
+	interval time.Duration,
+	gracePeriod time.Duration,
+	driver drivers.ProblemEnvironmentDriver,
+) *OrphanCollector {
+	return &OrphanCollector{
+		Client:      client,
+		recorder:    recorder,
+		workerName:  workerName,
+		interval:    interval,
+		gracePeriod: gracePeriod,
+		driver:      driver,
+		foundAt:     map[string]time.Time{},
+		now:         time.Now,
+	}
+}
+
+var _ manager.Runnable = &OrphanCollector{}
+
+// Start implements manager.Runnable
+func (c *OrphanCollector) Start(ctx context.Context) error {
+	log := log.FromContext(ctx)
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	// orphans are collected on startup first, as nclet may have crashed while destroying
+	for {
+		if err := c.collect(ctx); err != nil {
+			log.Error(err, "failed to collect orphans")
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *OrphanCollector) collect(ctx context.Context) error {
+	log := log.FromContext(ctx)
+
+	// instances must be listed before ProblemEnvironments,
+	// otherwise the ones deployed in the meantime are considered as orphans
+	instances, err := c.driver.ListInstances(ctx)
+	if err != nil {
+		return err
+	}
+
+	problemEnvironments := netconv1alpha1.ProblemEnvironmentList{}
+	if err := c.List(ctx, &problemEnvironments); err != nil {
+		return err
+	}
+
+	known := map[string]struct{}{}
+	for _, problemEnvironment := range problemEnvironments.Items {
+		if problemEnvironment.Spec.WorkerName == c.workerName {
+			known[problemEnvironment.Name] = struct{}{}
+		}
+	}
+
+	// Worker may not be created by HeartbeatAgent yet on startup
+	worker := netconv1alpha1.Worker{}
+	if err := c.Get(ctx, types.NamespacedName{Name: c.workerName}, &worker); client.IgnoreNotFound(err) != nil {
+		return err
+	}
+	worker.Name = c.workerName
+
+	foundAt := map[string]time.Time{}
+	for _, name := range instances {
+		if _, ok := known[name]; ok {
+			continue
+		}
+
+		found, ok := c.foundAt[name]
+		if !ok {
+			found = c.now()
+			log.Info("found orphaned instance", "name", name)
+			c.recorder.Eventf(
+				&worker,
+				corev1.EventTypeWarning,
+				netconv1alpha1.WorkerEventOrphanFound,
+				"Found instance %s without ProblemEnvironment",
+				name,
+			)
+		}
+		foundAt[name] = found
+
+		if c.gracePeriod <= 0 || c.now().Sub(found) < c.gracePeriod {
+			continue
+		}
+
+		if err := c.driver.DestroyInstance(ctx, name); err != nil {
+			log.Error(err, "failed to destroy orphaned instance", "name", name)
+			c.recorder.Eventf(
+				&worker,
+				corev1.EventTypeWarning,
+				netconv1alpha1.WorkerEventOrphanDestroyFailed,
+				"Failed to destroy instance %s without ProblemEnvironment: %s",
+				name, err,
+			)
+			continue
+		}
+
+		log.Info("destroyed orphaned instance", "name", name)
+		c.recorder.Eventf(
+			&worker,
+			corev1.EventTypeNormal,
+			netconv1alpha1.WorkerEventOrphanDestroyed,
+			"Destroyed instance %s without ProblemEnvironment for %s",
+			name, c.gracePeriod,
+		)
+		orphansDestroyedTotal.Inc()
+		delete(foundAt, name)
+	}
+
+	c.foundAt = foundAt
+	orphans.Set(float64(len(foundAt)))
+
+	return nil
+}
@@ -0,0 +1,168 @@
+package controllers
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	netconv1alpha1 "github.com/janog-netcon/netcon-problem-management-subsystem/api/v1alpha1"
+	"github.com/janog-netcon/netcon-problem-management-subsystem/controllers/nclet/drivers"
+)
+
+func newOrphanTestProblemEnvironment(name, workerName string) *netconv1alpha1.ProblemEnvironment {
+	problemEnvironment := &netconv1alpha1.ProblemEnvironment{}
+	problemEnvironment.Namespace = "default"
+	problemEnvironment.Name = name
+	problemEnvironment.Spec.WorkerName = workerName
+	problemEnvironment.Spec.TopologyFile.ConfigMapRef.Name = "topology-tst-001"
+	problemEnvironment.Spec.TopologyFile.ConfigMapRef.Key = "manifest.yml"
+	return problemEnvironment
+}
+
+// newOrphanTestCollector returns OrphanCollector for worker-001 with the fake driver
+// in which tst-001, tst-002 and tst-003 are deployed.
+func newOrphanTestCollector(
+	t *testing.T,
+	gracePeriod time.Duration,
+	clock *fakeClock,
+	objects ...client.Object,
+) (*OrphanCollector, *drivers.FakeProblemEnvironmentDriver, *record.FakeRecorder) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := netconv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	topology := &corev1.ConfigMap{}
+	topology.Namespace = "default"
+	topology.Name = "topology-tst-001"
+	topology.Data = map[string]string{
+		"manifest.yml": "name: tst-001\ntopology:\n  nodes:\n    host1:\n      kind: linux\n",
+	}
+
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(append(objects, topology)...).
+		Build()
+
+	driver := drivers.NewFakeProblemEnvironmentDriver(0, 0)
+	for _, name := range []string{"tst-001", "tst-002", "tst-003"} {
+		if err := driver.Deploy(context.Background(), c, *newOrphanTestProblemEnvironment(name, "worker-001")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	recorder := record.NewFakeRecorder(100)
+	collector := NewOrphanCollector(c, recorder, "worker-001", time.Minute, gracePeriod, driver)
+	collector.now = clock.Now
+
+	return collector, driver, recorder
+}
+
+// drainEvents returns the reasons of events recorded since the last call.
+func drainEvents(recorder *record.FakeRecorder) []string {
+	reasons := []string{}
+	for {
+		select {
+		case event := <-recorder.Events:
+			// FakeRecorder formats events as "<type> <reason> <message>"
+			reasons = append(reasons, strings.Fields(event)[1])
+		default:
+			return reasons
+		}
+	}
+}
+
+func listInstances(t *testing.T, driver *drivers.FakeProblemEnvironmentDriver) string {
+	instances, err := driver.ListInstances(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Join(instances, ",")
+}
+
+func TestOrphanCollectorDestroysOrphansAfterGracePeriod(t *testing.T) {
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+
+	collector, driver, recorder := newOrphanTestCollector(t, 10*time.Minute, clock,
+		// tst-001 is managed by this nclet
+		newOrphanTestProblemEnvironment("tst-001", "worker-001"),
+		// tst-002 is scheduled to another Worker, so the instance on worker-001 is orphaned
+		newOrphanTestProblemEnvironment("tst-002", "worker-002"),
+		// tst-003 has no ProblemEnvironment
+	)
+
+	if err := collector.collect(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if reasons := drainEvents(recorder); len(reasons) != 2 ||
+		reasons[0] != netconv1alpha1.WorkerEventOrphanFound ||
+		reasons[1] != netconv1alpha1.WorkerEventOrphanFound {
+		t.Errorf("tst-002 and tst-003 should be found as orphans: %v", reasons)
+	}
+
+	// orphans are kept within the grace period, and reported only once
+	clock.Advance(5 * time.Minute)
+	if err := collector.collect(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if instances := listInstances(t, driver); instances != "tst-001,tst-002,tst-003" {
+		t.Errorf("instances should be kept within the grace period: %s", instances)
+	}
+	if reasons := drainEvents(recorder); len(reasons) != 0 {
+		t.Errorf("orphans should be reported only once: %v", reasons)
+	}
+
+	// tst-003 gets ProblemEnvironment before the grace period passes
+	if err := collector.Create(ctx, newOrphanTestProblemEnvironment("tst-003", "worker-001")); err != nil {
+		t.Fatal(err)
+	}
+
+	clock.Advance(6 * time.Minute)
+	if err := collector.collect(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if instances := listInstances(t, driver); instances != "tst-001,tst-003" {
+		t.Errorf("only tst-002 should be destroyed: %s", instances)
+	}
+	if reasons := drainEvents(recorder); len(reasons) != 1 || reasons[0] != netconv1alpha1.WorkerEventOrphanDestroyed {
+		t.Errorf("tst-002 should be destroyed: %v", reasons)
+	}
+	if len(collector.foundAt) != 0 {
+		t.Errorf("no orphans should be left: %v", collector.foundAt)
+	}
+}
+
+func TestOrphanCollectorOnlyReportsWithoutGracePeriod(t *testing.T) {
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+
+	collector, driver, recorder := newOrphanTestCollector(t, 0, clock,
+		newOrphanTestProblemEnvironment("tst-001", "worker-001"),
+	)
+
+	for range 3 {
+		if err := collector.collect(ctx); err != nil {
+			t.Fatal(err)
+		}
+		clock.Advance(24 * time.Hour)
+	}
+
+	if instances := listInstances(t, driver); instances != "tst-001,tst-002,tst-003" {
+		t.Errorf("orphans should never be destroyed without the grace period: %s", instances)
+	}
+	if reasons := drainEvents(recorder); len(reasons) != 2 {
+		t.Errorf("tst-002 and tst-003 should be reported once: %v", reasons)
+	}
+}
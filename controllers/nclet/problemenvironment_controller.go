@@ -18,6 +18,7 @@ package controllers
 
 import (
 	"context"
+	"fmt"
 	"reflect"
 	"time"
 
@@ -37,9 +38,20 @@ import (
 	"github.com/pkg/errors"
 )
 
-const ProblemEnvironmentFinalizer string = "problemenvironment.netcon.janog.gr.jp"
+const ProblemEnvironmentFinalizer string = netconv1alpha1.ProblemEnvironmentFinalizer
 const StatusRefreshInterval = 5 * time.Second
 
+const (
+	// DefaultMaxDeployAttempts is the default number of attempts to deploy ProblemEnvironment before giving up
+	DefaultMaxDeployAttempts = 3
+
+	// DefaultDeployBackoff is the default period to wait before the first retry of deploying ProblemEnvironment
+	DefaultDeployBackoff = 10 * time.Second
+
+	// maxDeployBackoff caps the exponential backoff between attempts to deploy ProblemEnvironment
+	maxDeployBackoff = 5 * time.Minute
+)
+
 // ProblemEnvironmentReconciler reconciles a ProblemEnvironment object
 type ProblemEnvironmentReconciler struct {
 	client.Client
@@ -48,10 +60,24 @@ type ProblemEnvironmentReconciler struct {
 
 	MaxConcurrentReconciles int
 
+	// MaxDeployAttempts is the number of attempts to deploy ProblemEnvironment before marking it as Failed
+	MaxDeployAttempts int
+
+	// DeployBackoff is the period to wait before the first retry, doubled for each failure
+	DeployBackoff time.Duration
+
+	// DeployTimeout is the timeout of each attempt to deploy ProblemEnvironment
+	// without DeployTimeout in its spec. If DeployTimeout is 0, it never times out.
+	DeployTimeout time.Duration
+
 	// WorkerName is the name of worker where nclet places
 	WorkerName string
 
 	ProblemEnvironmentDriver drivers.ProblemEnvironmentDriver
+
+	// Sessions tracks SSH sessions to hibernate idle ProblemEnvironments.
+	// If Sessions is nil, ProblemEnvironments never hibernate.
+	Sessions *SessionTracker
 }
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
@@ -97,6 +123,11 @@ func (r *ProblemEnvironmentReconciler) Reconcile(ctx context.Context, req ctrl.R
 		return r.update(ctx, &problemEnvironment, ctrl.Result{})
 	}
 
+	if util.IsProblemEnvironmentResetRequested(&problemEnvironment) {
+		log.Info("reset is requested, destroying instance to redeploy")
+		return r.reset(ctx, &problemEnvironment)
+	}
+
 	deployed := util.GetProblemEnvironmentCondition(
 		&problemEnvironment,
 		netconv1alpha1.ProblemEnvironmentConditionDeployed,
@@ -155,10 +186,99 @@ func (r *ProblemEnvironmentReconciler) cleanup(
 		return r.updateStatus(ctx, problemEnvironment, ctrl.Result{})
 	}
 
+	if r.Sessions != nil {
+		r.Sessions.Forget(problemEnvironment.Name)
+	}
+
 	controllerutil.RemoveFinalizer(problemEnvironment, ProblemEnvironmentFinalizer)
 	return r.update(ctx, problemEnvironment, ctrl.Result{})
 }
 
+// reset destroys ProblemEnvironment and marks it as not deployed to redeploy.
+// The name and the password are kept as ProblemEnvironment itself is not recreated.
+func (r *ProblemEnvironmentReconciler) reset(
+	ctx context.Context,
+	problemEnvironment *netconv1alpha1.ProblemEnvironment,
+) (ctrl.Result, error) {
+	log := log.FromContext(ctx)
+
+	r.Recorder.Eventf(
+		problemEnvironment,
+		corev1.EventTypeNormal,
+		netconv1alpha1.ProblemEnvironmentEventResetting,
+		"Resetting ProblemEnvironment on %s",
+		r.WorkerName,
+	)
+
+	if err := r.ProblemEnvironmentDriver.Destroy(ctx, r.Client, *problemEnvironment); err != nil {
+		message := "failed to destroy ProblemEnvironment to reset"
+		log.Error(err, message)
+		r.Recorder.Event(
+			problemEnvironment,
+			corev1.EventTypeWarning,
+			netconv1alpha1.ProblemEnvironmentEventResetFailed,
+			message,
+		)
+		util.SetProblemEnvironmentCondition(
+			problemEnvironment,
+			netconv1alpha1.ProblemEnvironmentConditionResetting,
+			metav1.ConditionTrue,
+			"DestroyFailed",
+			message,
+		)
+		return r.updateStatus(ctx, problemEnvironment, ctrl.Result{RequeueAfter: StatusRefreshInterval})
+	}
+
+	util.SetProblemEnvironmentCondition(
+		problemEnvironment,
+		netconv1alpha1.ProblemEnvironmentConditionResetting,
+		metav1.ConditionTrue,
+		"Redeploying",
+		"ProblemEnvironment is destroyed and being redeployed",
+	)
+	util.SetProblemEnvironmentCondition(
+		problemEnvironment,
+		netconv1alpha1.ProblemEnvironmentConditionDeployed,
+		metav1.ConditionFalse,
+		"Resetting",
+		"ProblemEnvironment is destroyed to reset",
+	)
+	// reset gives ProblemEnvironment failed to deploy another chance
+	if util.GetProblemEnvironmentCondition(
+		problemEnvironment,
+		netconv1alpha1.ProblemEnvironmentConditionFailed,
+	) == metav1.ConditionTrue {
+		util.SetProblemEnvironmentCondition(
+			problemEnvironment,
+			netconv1alpha1.ProblemEnvironmentConditionFailed,
+			metav1.ConditionFalse,
+			"Resetting",
+			"ProblemEnvironment is being redeployed",
+		)
+	}
+	// paused containers are destroyed as well
+	if util.GetProblemEnvironmentCondition(
+		problemEnvironment,
+		netconv1alpha1.ProblemEnvironmentConditionHibernated,
+	) == metav1.ConditionTrue {
+		util.SetProblemEnvironmentCondition(
+			problemEnvironment,
+			netconv1alpha1.ProblemEnvironmentConditionHibernated,
+			metav1.ConditionFalse,
+			"Resetting",
+			"ProblemEnvironment is being redeployed",
+		)
+	}
+	if r.Sessions != nil {
+		r.Sessions.SetHibernated(problemEnvironment.Name, false)
+	}
+	problemEnvironment.Status.DeployAttempts = 0
+	problemEnvironment.Status.LastDeployFailureTime = nil
+	problemEnvironment.Status.Containers = nil
+	problemEnvironment.Status.ObservedResetGeneration = problemEnvironment.Spec.ResetGeneration
+	return r.updateStatus(ctx, problemEnvironment, ctrl.Result{})
+}
+
 func (r *ProblemEnvironmentReconciler) updateContainerStatus(
 	ctx context.Context,
 	problemEnvironment *netconv1alpha1.ProblemEnvironment,
@@ -189,58 +309,235 @@ func (r *ProblemEnvironmentReconciler) deploy(
 ) (ctrl.Result, error) {
 	log := log.FromContext(ctx)
 
+	if util.GetProblemEnvironmentCondition(
+		problemEnvironment,
+		netconv1alpha1.ProblemEnvironmentConditionFailed,
+	) == metav1.ConditionTrue {
+		log.V(1).Info("ProblemEnvironment failed to deploy, waiting to be replaced")
+		return ctrl.Result{}, nil
+	}
+
+	if backoff := r.remainingDeployBackoff(problemEnvironment); backoff > 0 {
+		log.V(1).Info("backing off before retrying to deploy", "after", backoff)
+		return ctrl.Result{RequeueAfter: backoff}, nil
+	}
+
 	status, _ := r.ProblemEnvironmentDriver.Check(ctx, r.Client, *problemEnvironment)
 
 	switch status {
-	case drivers.StatusInit:
-		r.Recorder.Eventf(
-			problemEnvironment,
-			corev1.EventTypeNormal,
-			netconv1alpha1.ProblemEnvironmentEventDeploying,
-			"Starting to deploy ProblemEnvironment on %s",
-			r.WorkerName,
-		)
-		start := time.Now()
-		r.ProblemEnvironmentDriver.Deploy(ctx, r.Client, *problemEnvironment)
-		elapsed := time.Since(start)
-		r.Recorder.Eventf(
+	case drivers.StatusDeployed:
+		// nclet may be restarted after deploying ProblemEnvironment before updating status
+		log.Info("ProblemEnvironment is already deployed")
+		return r.markDeployed(ctx, problemEnvironment)
+	case drivers.StatusError:
+		return r.failDeploy(ctx, problemEnvironment, "DeployFailed", errors.New("ProblemEnvironment is partially deployed"))
+	}
+
+	r.Recorder.Eventf(
+		problemEnvironment,
+		corev1.EventTypeNormal,
+		netconv1alpha1.ProblemEnvironmentEventDeploying,
+		"Starting to deploy ProblemEnvironment on %s",
+		r.WorkerName,
+	)
+	// clab is killed when deploying times out, and then the partial lab is destroyed by failDeploy
+	deployCtx := ctx
+	timeout := r.deployTimeout(problemEnvironment)
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		deployCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	if err := r.ProblemEnvironmentDriver.Deploy(deployCtx, r.Client, *problemEnvironment); err != nil {
+		if errors.Is(deployCtx.Err(), context.DeadlineExceeded) {
+			err = errors.Wrapf(err, "timed out after %s", timeout)
+			return r.failDeploy(ctx, problemEnvironment, "DeployTimedOut", err)
+		}
+		return r.failDeploy(ctx, problemEnvironment, "DeployFailed", err)
+	}
+	elapsed := time.Since(start)
+	r.Recorder.Eventf(
+		problemEnvironment,
+		corev1.EventTypeNormal,
+		netconv1alpha1.ProblemEnvironmentEventDeployed,
+		"Deployed ProblemEnvironment in %s",
+		elapsed.String(),
+	)
+	return r.markDeployed(ctx, problemEnvironment)
+}
+
+func (r *ProblemEnvironmentReconciler) markDeployed(
+	ctx context.Context,
+	problemEnvironment *netconv1alpha1.ProblemEnvironment,
+) (ctrl.Result, error) {
+	log := log.FromContext(ctx)
+
+	message := "ProblemEnvironment is deployed"
+	log.Info(message)
+	util.SetProblemEnvironmentCondition(
+		problemEnvironment,
+		netconv1alpha1.ProblemEnvironmentConditionDeployed,
+		metav1.ConditionTrue,
+		"Deployed",
+		message,
+	)
+	problemEnvironment.Status.DeployAttempts = 0
+	problemEnvironment.Status.LastDeployFailureTime = nil
+
+	if util.GetProblemEnvironmentCondition(
+		problemEnvironment,
+		netconv1alpha1.ProblemEnvironmentConditionResetting,
+	) == metav1.ConditionTrue {
+		r.Recorder.Event(
 			problemEnvironment,
 			corev1.EventTypeNormal,
-			netconv1alpha1.ProblemEnvironmentEventDeployed,
-			"Deployed ProblemEnvironment in %s",
-			elapsed.String(),
+			netconv1alpha1.ProblemEnvironmentEventReset,
+			"Reset ProblemEnvironment",
 		)
-		reason := "Deployed"
-		message := "ProblemEnvironment is deployed"
-		log.Info(message)
 		util.SetProblemEnvironmentCondition(
 			problemEnvironment,
-			netconv1alpha1.ProblemEnvironmentConditionDeployed,
-			metav1.ConditionTrue,
-			reason,
-			message,
+			netconv1alpha1.ProblemEnvironmentConditionResetting,
+			metav1.ConditionFalse,
+			"Reset",
+			"ProblemEnvironment is reset",
 		)
-		return r.check(ctx, problemEnvironment)
-	default: // StatusReady, StatusError
-		err := errors.New("unexpected state")
-		message := "internal error"
-		log.Error(err, message)
+	}
+
+	// status is always updated here, otherwise the conditions above are lost
+	// when container statuses are not changed
+	_, containerStatuses := r.ProblemEnvironmentDriver.Check(ctx, r.Client, *problemEnvironment)
+	problemEnvironment.Status.Containers = containerStatuses
+	return r.updateStatus(ctx, problemEnvironment, ctrl.Result{RequeueAfter: StatusRefreshInterval})
+}
+
+// failDeploy cleans up ProblemEnvironment partially deployed and records the failure with the reason.
+// ProblemEnvironment is marked as Failed when it reaches the max attempts.
+func (r *ProblemEnvironmentReconciler) failDeploy(
+	ctx context.Context,
+	problemEnvironment *netconv1alpha1.ProblemEnvironment,
+	reason string,
+	deployErr error,
+) (ctrl.Result, error) {
+	log := log.FromContext(ctx)
+
+	log.Error(deployErr, "failed to deploy ProblemEnvironment")
+
+	// the next attempt should start from scratch
+	if err := r.ProblemEnvironmentDriver.Destroy(ctx, r.Client, *problemEnvironment); err != nil {
+		log.Error(err, "failed to clean up ProblemEnvironment partially deployed")
+	}
+
+	now := metav1.Now()
+	problemEnvironment.Status.DeployAttempts++
+	problemEnvironment.Status.LastDeployFailureTime = &now
+
+	attempts := problemEnvironment.Status.DeployAttempts
+	message := fmt.Sprintf("failed to deploy (attempt %d/%d): %s", attempts, r.MaxDeployAttempts, deployErr)
+	r.Recorder.Event(
+		problemEnvironment,
+		corev1.EventTypeWarning,
+		netconv1alpha1.ProblemEnvironmentEventDeployFailed,
+		message,
+	)
+	util.SetProblemEnvironmentCondition(
+		problemEnvironment,
+		netconv1alpha1.ProblemEnvironmentConditionDeployed,
+		metav1.ConditionFalse,
+		reason,
+		message,
+	)
+
+	if attempts < r.MaxDeployAttempts {
+		return r.updateStatus(ctx, problemEnvironment, ctrl.Result{RequeueAfter: r.deployBackoff(attempts)})
+	}
+
+	r.Recorder.Eventf(
+		problemEnvironment,
+		corev1.EventTypeWarning,
+		netconv1alpha1.ProblemEnvironmentEventFailed,
+		"Gave up deploying ProblemEnvironment after %d attempts",
+		attempts,
+	)
+	util.SetProblemEnvironmentCondition(
+		problemEnvironment,
+		netconv1alpha1.ProblemEnvironmentConditionFailed,
+		metav1.ConditionTrue,
+		reason,
+		message,
+	)
+	if util.GetProblemEnvironmentCondition(
+		problemEnvironment,
+		netconv1alpha1.ProblemEnvironmentConditionResetting,
+	) == metav1.ConditionTrue {
 		util.SetProblemEnvironmentCondition(
 			problemEnvironment,
-			netconv1alpha1.ProblemEnvironmentConditionDeployed,
-			metav1.ConditionTrue,
-			"DeployFailed",
-			message,
+			netconv1alpha1.ProblemEnvironmentConditionResetting,
+			metav1.ConditionFalse,
+			"Failed",
+			"ProblemEnvironment failed to redeploy",
 		)
-		return r.updateStatus(ctx, problemEnvironment, ctrl.Result{})
 	}
+	return r.updateStatus(ctx, problemEnvironment, ctrl.Result{})
+}
+
+// deployTimeout returns the timeout of each attempt to deploy ProblemEnvironment, or 0 if it never times out.
+func (r *ProblemEnvironmentReconciler) deployTimeout(problemEnvironment *netconv1alpha1.ProblemEnvironment) time.Duration {
+	if problemEnvironment.Spec.DeployTimeout != nil {
+		return problemEnvironment.Spec.DeployTimeout.Duration
+	}
+	return r.DeployTimeout
+}
+
+// deployBackoff returns the period to wait before the next attempt after the given attempts failed.
+func (r *ProblemEnvironmentReconciler) deployBackoff(attempts int) time.Duration {
+	backoff := r.DeployBackoff
+	for i := 1; i < attempts && backoff < maxDeployBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, maxDeployBackoff)
+}
+
+// remainingDeployBackoff returns the rest of the period to wait before retrying to deploy.
+func (r *ProblemEnvironmentReconciler) remainingDeployBackoff(problemEnvironment *netconv1alpha1.ProblemEnvironment) time.Duration {
+	lastFailure := problemEnvironment.Status.LastDeployFailureTime
+	if lastFailure == nil || problemEnvironment.Status.DeployAttempts == 0 {
+		return 0
+	}
+	return time.Until(lastFailure.Add(r.deployBackoff(problemEnvironment.Status.DeployAttempts)))
 }
 
 func (r *ProblemEnvironmentReconciler) check(
 	ctx context.Context,
 	problemEnvironment *netconv1alpha1.ProblemEnvironment,
 ) (ctrl.Result, error) {
+	if r.Sessions != nil {
+		hibernated := util.GetProblemEnvironmentCondition(
+			problemEnvironment,
+			netconv1alpha1.ProblemEnvironmentConditionHibernated,
+		) == metav1.ConditionTrue
+
+		if r.Sessions.Hibernated(problemEnvironment.Name) && !hibernated {
+			// the status update after hibernated may have failed
+			return r.markHibernated(ctx, problemEnvironment)
+		}
+		if !r.Sessions.Hibernated(problemEnvironment.Name) && hibernated {
+			// the status update after woken up by SSHServer may have failed,
+			// or nclet may have restarted while hibernated
+			return r.wakeUp(ctx, problemEnvironment)
+		}
+		if hibernated {
+			// container statuses are kept while hibernated, as paused containers don't respond to probes
+			return ctrl.Result{RequeueAfter: StatusRefreshInterval}, nil
+		}
+		if r.isIdle(problemEnvironment) {
+			return r.hibernate(ctx, problemEnvironment)
+		}
+	}
+
 	_, containerDetailStatuses := r.ProblemEnvironmentDriver.Check(ctx, r.Client, *problemEnvironment)
+	containerDetailStatuses = r.restartExitedContainers(ctx, problemEnvironment, containerDetailStatuses)
 
 	return r.updateContainerStatus(
 		ctx,
@@ -249,11 +546,191 @@ func (r *ProblemEnvironmentReconciler) check(
 	)
 }
 
+// isIdle returns true if ProblemEnvironment assigned has had no SSH sessions longer than HibernateAfter.
+func (r *ProblemEnvironmentReconciler) isIdle(problemEnvironment *netconv1alpha1.ProblemEnvironment) bool {
+	hibernateAfter := problemEnvironment.Spec.HibernateAfter
+	if hibernateAfter == nil || hibernateAfter.Duration <= 0 {
+		return false
+	}
+
+	assigned := util.FindProblemEnvironmentCondition(
+		problemEnvironment,
+		netconv1alpha1.ProblemEnvironmentConditionAssigned,
+	)
+	if assigned == nil || assigned.Status != metav1.ConditionTrue {
+		return false
+	}
+
+	idleSince, ok := r.Sessions.IdleSince(problemEnvironment.Name)
+	if !ok {
+		return false
+	}
+	// sessions before assigned belong to admins checking ProblemEnvironment
+	if assigned.LastTransitionTime.After(idleSince) {
+		idleSince = assigned.LastTransitionTime.Time
+	}
+
+	return time.Since(idleSince) >= hibernateAfter.Duration
+}
+
+// hibernate pauses the containers of ProblemEnvironment idle.
+// It's woken up by SSHServer on the next SSH login.
+func (r *ProblemEnvironmentReconciler) hibernate(
+	ctx context.Context,
+	problemEnvironment *netconv1alpha1.ProblemEnvironment,
+) (ctrl.Result, error) {
+	log := log.FromContext(ctx)
+
+	unlock := r.Sessions.Lock(problemEnvironment.Name)
+	defer unlock()
+
+	// a session may have begun while waiting for the lock
+	if !r.isIdle(problemEnvironment) {
+		return ctrl.Result{Requeue: true}, nil
+	}
+
+	if err := r.ProblemEnvironmentDriver.Hibernate(ctx, r.Client, *problemEnvironment); err != nil {
+		log.Error(err, "failed to hibernate ProblemEnvironment")
+		// resume the containers paused partially
+		if err := r.ProblemEnvironmentDriver.WakeUp(ctx, r.Client, *problemEnvironment); err != nil {
+			log.Error(err, "failed to wake up ProblemEnvironment hibernated partially")
+			r.Sessions.SetHibernated(problemEnvironment.Name, true)
+		}
+		return ctrl.Result{}, err
+	}
+	r.Sessions.SetHibernated(problemEnvironment.Name, true)
+
+	log.Info("hibernated ProblemEnvironment")
+	r.Recorder.Eventf(
+		problemEnvironment,
+		corev1.EventTypeNormal,
+		netconv1alpha1.ProblemEnvironmentEventHibernated,
+		"No SSH sessions for %s, containers are paused until the next SSH login",
+		problemEnvironment.Spec.HibernateAfter.Duration,
+	)
+	return r.markHibernated(ctx, problemEnvironment)
+}
+
+// wakeUp resumes the containers of ProblemEnvironment marked as Hibernated but not tracked
+// as hibernated by SessionTracker, and clears the condition. Resuming running containers does nothing.
+func (r *ProblemEnvironmentReconciler) wakeUp(
+	ctx context.Context,
+	problemEnvironment *netconv1alpha1.ProblemEnvironment,
+) (ctrl.Result, error) {
+	log := log.FromContext(ctx)
+
+	unlock := r.Sessions.Lock(problemEnvironment.Name)
+	defer unlock()
+
+	if err := r.ProblemEnvironmentDriver.WakeUp(ctx, r.Client, *problemEnvironment); err != nil {
+		log.Error(err, "failed to wake up ProblemEnvironment")
+		return ctrl.Result{}, err
+	}
+
+	log.Info("woke up ProblemEnvironment marked as hibernated")
+	util.SetProblemEnvironmentCondition(
+		problemEnvironment,
+		netconv1alpha1.ProblemEnvironmentConditionHibernated,
+		metav1.ConditionFalse,
+		"WokeUp",
+		"Containers are resumed as they are not tracked as hibernated",
+	)
+	return r.updateStatus(ctx, problemEnvironment, ctrl.Result{Requeue: true})
+}
+
+func (r *ProblemEnvironmentReconciler) markHibernated(
+	ctx context.Context,
+	problemEnvironment *netconv1alpha1.ProblemEnvironment,
+) (ctrl.Result, error) {
+	util.SetProblemEnvironmentCondition(
+		problemEnvironment,
+		netconv1alpha1.ProblemEnvironmentConditionHibernated,
+		metav1.ConditionTrue,
+		"Idle",
+		"Containers are paused until the next SSH login",
+	)
+	return r.updateStatus(ctx, problemEnvironment, ctrl.Result{RequeueAfter: StatusRefreshInterval})
+}
+
+// restartExitedContainers restarts the containers exited according to RestartPolicy.
+// RestartCount of each container is carried over from the current status.
+func (r *ProblemEnvironmentReconciler) restartExitedContainers(
+	ctx context.Context,
+	problemEnvironment *netconv1alpha1.ProblemEnvironment,
+	containerStatuses []netconv1alpha1.ContainerStatus,
+) []netconv1alpha1.ContainerStatus {
+	log := log.FromContext(ctx)
+
+	restartCounts := map[string]int{}
+	for _, containerStatus := range problemEnvironment.Status.Containers {
+		restartCounts[containerStatus.Name] = containerStatus.RestartCount
+	}
+
+	policy := problemEnvironment.Spec.RestartPolicy
+	backoffLimit := netconv1alpha1.DefaultRestartBackoffLimit
+	if policy != nil && policy.BackoffLimit != nil {
+		backoffLimit = *policy.BackoffLimit
+	}
+
+	for i := range containerStatuses {
+		containerStatus := &containerStatuses[i]
+		containerStatus.RestartCount = restartCounts[containerStatus.Name]
+
+		if policy == nil || policy.Type != netconv1alpha1.RestartPolicyOnFailure {
+			continue
+		}
+
+		// ContainerName is empty when the container couldn't be inspected
+		if containerStatus.Running || containerStatus.ContainerName == "" {
+			continue
+		}
+
+		if containerStatus.RestartCount >= backoffLimit {
+			continue
+		}
+
+		// failures are also counted not to retry forever
+		containerStatus.RestartCount++
+		attempt := fmt.Sprintf("%d/%d", containerStatus.RestartCount, backoffLimit)
+
+		if err := r.ProblemEnvironmentDriver.RestartContainer(
+			ctx, r.Client, *problemEnvironment, containerStatus.Name,
+		); err != nil {
+			log.Error(err, "failed to restart container", "container", containerStatus.Name)
+			r.Recorder.Eventf(
+				problemEnvironment,
+				corev1.EventTypeWarning,
+				netconv1alpha1.ProblemEnvironmentEventContainerRestartFailed,
+				"Failed to restart container %s (%s): %s",
+				containerStatus.Name, attempt, err,
+			)
+			continue
+		}
+
+		log.Info("restarted container", "container", containerStatus.Name, "attempt", attempt)
+		r.Recorder.Eventf(
+			problemEnvironment,
+			corev1.EventTypeNormal,
+			netconv1alpha1.ProblemEnvironmentEventContainerRestarted,
+			"Restarted container %s exited (%s)",
+			containerStatus.Name, attempt,
+		)
+	}
+
+	return containerStatuses
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *ProblemEnvironmentReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	if r.MaxConcurrentReconciles == 0 {
 		r.MaxConcurrentReconciles = 1
 	}
+	if r.MaxDeployAttempts == 0 {
+		r.MaxDeployAttempts = DefaultMaxDeployAttempts
+	}
+	if r.DeployBackoff == 0 {
+		r.DeployBackoff = DefaultDeployBackoff
+	}
 
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&netconv1alpha1.ProblemEnvironment{}).
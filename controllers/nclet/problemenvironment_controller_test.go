@@ -0,0 +1,367 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	netconv1alpha1 "github.com/janog-netcon/netcon-problem-management-subsystem/api/v1alpha1"
+	"github.com/janog-netcon/netcon-problem-management-subsystem/controllers/nclet/drivers"
+	util "github.com/janog-netcon/netcon-problem-management-subsystem/pkg/util"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics/server"
+)
+
+var _ = Describe("ProblemEnvironment controller", func() {
+	ctx := context.Background()
+
+	var stopFunc func()
+	var driver *drivers.FakeProblemEnvironmentDriver
+	var sessions *SessionTracker
+
+	// createProblemEnvironment creates ProblemEnvironment from the manifest and schedules it as controller-manager does
+	createProblemEnvironment := func(name string) {
+		problemEnvironment := netconv1alpha1.ProblemEnvironment{}
+		err := loadManifest(filepath.Join("tests", "problemenvironments", name+".yaml"), &problemEnvironment)
+		Expect(err).NotTo(HaveOccurred())
+
+		err = k8sClient.Create(ctx, &problemEnvironment)
+		Expect(err).NotTo(HaveOccurred())
+
+		util.SetProblemEnvironmentCondition(
+			&problemEnvironment,
+			netconv1alpha1.ProblemEnvironmentConditionScheduled,
+			metav1.ConditionTrue,
+			"Test", "test",
+		)
+		err = k8sClient.Status().Update(ctx, &problemEnvironment)
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	getProblemEnvironment := func(name string) func() (*netconv1alpha1.ProblemEnvironment, error) {
+		return func() (*netconv1alpha1.ProblemEnvironment, error) {
+			problemEnvironment := netconv1alpha1.ProblemEnvironment{}
+			err := k8sClient.Get(ctx, client.ObjectKey{Namespace: "default", Name: name}, &problemEnvironment)
+			return &problemEnvironment, err
+		}
+	}
+
+	BeforeEach(func() {
+		configMap := corev1.ConfigMap{}
+		err := loadManifest(filepath.Join("tests", "configmaps", "topology-tst-001.yaml"), &configMap)
+		Expect(err).NotTo(HaveOccurred())
+		err = k8sClient.Create(ctx, &configMap)
+		Expect(err).NotTo(HaveOccurred())
+
+		mgr, err := ctrl.NewManager(cfg, ctrl.Options{
+			Scheme: scheme.Scheme,
+			Metrics: server.Options{
+				BindAddress: "0",
+			},
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		driver = drivers.NewFakeProblemEnvironmentDriver(0, 0)
+		sessions = NewSessionTracker()
+		err = (&ProblemEnvironmentReconciler{
+			Client:                   mgr.GetClient(),
+			Scheme:                   mgr.GetScheme(),
+			Recorder:                 mgr.GetEventRecorderFor("problemenvironment-controller"),
+			MaxDeployAttempts:        2,
+			DeployBackoff:            100 * time.Millisecond,
+			WorkerName:               "worker-001",
+			ProblemEnvironmentDriver: driver,
+			Sessions:                 sessions,
+		}).SetupWithManager(mgr)
+		Expect(err).NotTo(HaveOccurred())
+
+		ctx, cancel := context.WithCancel(ctx)
+		stopFunc = cancel
+		go func() {
+			err := mgr.Start(ctx)
+			if err != nil {
+				panic(err)
+			}
+		}()
+		time.Sleep(100 * time.Millisecond)
+	})
+
+	AfterEach(func() {
+		// ProblemEnvironments must be deleted while nclet is running to remove the finalizer
+		err := k8sClient.DeleteAllOf(ctx, &netconv1alpha1.ProblemEnvironment{}, client.InNamespace("default"))
+		Expect(err).ToNot(HaveOccurred())
+		Eventually(func() ([]netconv1alpha1.ProblemEnvironment, error) {
+			problemEnvironments := netconv1alpha1.ProblemEnvironmentList{}
+			err := k8sClient.List(ctx, &problemEnvironments, client.InNamespace("default"))
+			return problemEnvironments.Items, err
+		}).WithTimeout(5 * time.Second).Should(BeEmpty())
+
+		err = k8sClient.DeleteAllOf(ctx, &corev1.ConfigMap{}, client.InNamespace("default"))
+		Expect(err).ToNot(HaveOccurred())
+
+		stopFunc()
+		time.Sleep(100 * time.Millisecond)
+	})
+
+	It("should deploy ProblemEnvironment with a container per node", func() {
+		createProblemEnvironment("problemenvironment-tst-001")
+
+		Eventually(getProblemEnvironment("tst-001")).WithTimeout(5 * time.Second).Should(
+			WithTransform(func(problemEnvironment *netconv1alpha1.ProblemEnvironment) bool {
+				return util.GetProblemEnvironmentCondition(
+					problemEnvironment,
+					netconv1alpha1.ProblemEnvironmentConditionDeployed,
+				) == metav1.ConditionTrue
+			}, BeTrue()),
+		)
+
+		Eventually(getProblemEnvironment("tst-001")).WithTimeout(5 * time.Second).Should(
+			WithTransform(func(problemEnvironment *netconv1alpha1.ProblemEnvironment) []string {
+				ready := []string{}
+				for _, containerStatus := range problemEnvironment.Status.Containers {
+					if containerStatus.Ready {
+						ready = append(ready, containerStatus.Name)
+					}
+				}
+				return ready
+			}, ConsistOf("host1", "host2")),
+		)
+	})
+
+	It("should redeploy ProblemEnvironment when the reset is requested", func() {
+		createProblemEnvironment("problemenvironment-tst-001")
+
+		isDeployed := func(problemEnvironment *netconv1alpha1.ProblemEnvironment) bool {
+			return util.GetProblemEnvironmentCondition(
+				problemEnvironment,
+				netconv1alpha1.ProblemEnvironmentConditionDeployed,
+			) == metav1.ConditionTrue
+		}
+
+		Eventually(getProblemEnvironment("tst-001")).WithTimeout(5 * time.Second).Should(
+			WithTransform(isDeployed, BeTrue()),
+		)
+
+		// request the reset as gateway does
+		err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			problemEnvironment, err := getProblemEnvironment("tst-001")()
+			if err != nil {
+				return err
+			}
+			util.RequestProblemEnvironmentReset(problemEnvironment)
+			return k8sClient.Update(ctx, problemEnvironment)
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		Eventually(getProblemEnvironment("tst-001")).WithTimeout(5 * time.Second).Should(
+			WithTransform(func(problemEnvironment *netconv1alpha1.ProblemEnvironment) bool {
+				condition := util.FindProblemEnvironmentCondition(
+					problemEnvironment,
+					netconv1alpha1.ProblemEnvironmentConditionResetting,
+				)
+				return !util.IsProblemEnvironmentResetting(problemEnvironment) &&
+					condition != nil && condition.Reason == "Reset" &&
+					isDeployed(problemEnvironment)
+			}, BeTrue()),
+		)
+
+		problemEnvironment, err := getProblemEnvironment("tst-001")()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(problemEnvironment.Status.ObservedResetGeneration).To(Equal(problemEnvironment.Spec.ResetGeneration))
+		Expect(problemEnvironment.Spec.ResetGeneration).To(Equal(int64(1)))
+	})
+
+	It("should mark ProblemEnvironment as Failed after the max attempts", func() {
+		createProblemEnvironment("problemenvironment-tst-002")
+
+		Eventually(getProblemEnvironment("tst-002")).WithTimeout(5 * time.Second).Should(
+			WithTransform(func(problemEnvironment *netconv1alpha1.ProblemEnvironment) bool {
+				return util.GetProblemEnvironmentCondition(
+					problemEnvironment,
+					netconv1alpha1.ProblemEnvironmentConditionFailed,
+				) == metav1.ConditionTrue
+			}, BeTrue()),
+		)
+
+		problemEnvironment, err := getProblemEnvironment("tst-002")()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(problemEnvironment.Status.DeployAttempts).To(Equal(2))
+		Expect(util.GetProblemEnvironmentCondition(
+			problemEnvironment,
+			netconv1alpha1.ProblemEnvironmentConditionDeployed,
+		)).NotTo(Equal(metav1.ConditionTrue))
+	})
+
+	// containerStates returns "running" or "exited" with RestartCount of each container, like "running/1"
+	containerStates := func(problemEnvironment *netconv1alpha1.ProblemEnvironment) map[string]string {
+		states := map[string]string{}
+		for _, containerStatus := range problemEnvironment.Status.Containers {
+			state := "exited"
+			if containerStatus.Running {
+				state = "running"
+			}
+			states[containerStatus.Name] = fmt.Sprintf("%s/%d", state, containerStatus.RestartCount)
+		}
+		return states
+	}
+
+	It("should restart containers exited up to BackoffLimit with OnFailure", func() {
+		createProblemEnvironment("problemenvironment-tst-004")
+
+		// host1 is restarted once, and it's running after the next check
+		Eventually(getProblemEnvironment("tst-004")).WithTimeout(15 * time.Second).Should(
+			WithTransform(containerStates, Equal(map[string]string{
+				"host1": "running/1",
+				"host2": "running/0",
+			})),
+		)
+
+		// both exit, but host1 is not restarted anymore as it reached BackoffLimit
+		err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			problemEnvironment, err := getProblemEnvironment("tst-004")()
+			if err != nil {
+				return err
+			}
+			problemEnvironment.Annotations[drivers.FakeExitedNodesAnnotation] = "host1,host2"
+			return k8sClient.Update(ctx, problemEnvironment)
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		Eventually(getProblemEnvironment("tst-004")).WithTimeout(15 * time.Second).Should(
+			WithTransform(containerStates, Equal(map[string]string{
+				"host1": "exited/1",
+				"host2": "running/1",
+			})),
+		)
+		Consistently(getProblemEnvironment("tst-004")).WithTimeout(StatusRefreshInterval + time.Second).Should(
+			WithTransform(containerStates, HaveKeyWithValue("host1", "exited/1")),
+		)
+	})
+
+	It("should not restart containers exited with Never", func() {
+		createProblemEnvironment("problemenvironment-tst-005")
+
+		Eventually(getProblemEnvironment("tst-005")).WithTimeout(5 * time.Second).Should(
+			WithTransform(containerStates, Equal(map[string]string{
+				"host1": "exited/0",
+				"host2": "running/0",
+			})),
+		)
+		Consistently(getProblemEnvironment("tst-005")).WithTimeout(StatusRefreshInterval + time.Second).Should(
+			WithTransform(containerStates, HaveKeyWithValue("host1", "exited/0")),
+		)
+	})
+
+	It("should report containers not ready until they boot", func() {
+		createProblemEnvironment("problemenvironment-tst-003")
+
+		readiness := func(problemEnvironment *netconv1alpha1.ProblemEnvironment) []bool {
+			ready := []bool{}
+			for _, containerStatus := range problemEnvironment.Status.Containers {
+				ready = append(ready, containerStatus.Ready)
+			}
+			return ready
+		}
+
+		Eventually(getProblemEnvironment("tst-003")).WithTimeout(2 * time.Second).Should(
+			WithTransform(readiness, Equal([]bool{false, false})),
+		)
+		Eventually(getProblemEnvironment("tst-003")).WithTimeout(10 * time.Second).Should(
+			WithTransform(readiness, Equal([]bool{true, true})),
+		)
+	})
+
+	It("should hibernate idle ProblemEnvironment and wake it up", func() {
+		createProblemEnvironment("problemenvironment-tst-006")
+
+		Eventually(getProblemEnvironment("tst-006")).WithTimeout(5 * time.Second).Should(
+			WithTransform(func(problemEnvironment *netconv1alpha1.ProblemEnvironment) bool {
+				return util.GetProblemEnvironmentCondition(
+					problemEnvironment,
+					netconv1alpha1.ProblemEnvironmentConditionDeployed,
+				) == metav1.ConditionTrue
+			}, BeTrue()),
+		)
+
+		// assign ProblemEnvironment as gateway does, as only assigned ones hibernate
+		err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			problemEnvironment, err := getProblemEnvironment("tst-006")()
+			if err != nil {
+				return err
+			}
+			util.SetProblemEnvironmentCondition(
+				problemEnvironment,
+				netconv1alpha1.ProblemEnvironmentConditionAssigned,
+				metav1.ConditionTrue,
+				"Test", "test",
+			)
+			return k8sClient.Status().Update(ctx, problemEnvironment)
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		hibernated := func(problemEnvironment *netconv1alpha1.ProblemEnvironment) string {
+			condition := util.FindProblemEnvironmentCondition(
+				problemEnvironment,
+				netconv1alpha1.ProblemEnvironmentConditionHibernated,
+			)
+			if condition == nil {
+				return ""
+			}
+			return fmt.Sprintf("%s/%s", condition.Status, condition.Reason)
+		}
+		readiness := func(problemEnvironment *netconv1alpha1.ProblemEnvironment) []bool {
+			ready := []bool{}
+			for _, containerStatus := range problemEnvironment.Status.Containers {
+				ready = append(ready, containerStatus.Ready)
+			}
+			return ready
+		}
+
+		Eventually(getProblemEnvironment("tst-006")).WithTimeout(10 * time.Second).Should(
+			WithTransform(hibernated, Equal("True/Idle")),
+		)
+		Expect(driver.Paused("tst-006")).To(BeTrue())
+
+		// SSHServer wakes it up on login, but fails to update the status
+		end := sessions.Begin("tst-006")
+		problemEnvironment, err := getProblemEnvironment("tst-006")()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(driver.WakeUp(ctx, k8sClient, *problemEnvironment)).To(Succeed())
+		sessions.SetHibernated("tst-006", false)
+
+		Eventually(getProblemEnvironment("tst-006")).WithTimeout(10 * time.Second).Should(
+			WithTransform(hibernated, Equal("False/WokeUp")),
+		)
+		Eventually(getProblemEnvironment("tst-006")).WithTimeout(5 * time.Second).Should(
+			WithTransform(readiness, Equal([]bool{true, true})),
+		)
+
+		// it hibernates again after the session ends
+		end()
+		Eventually(getProblemEnvironment("tst-006")).WithTimeout(10 * time.Second).Should(
+			WithTransform(hibernated, Equal("True/Idle")),
+		)
+		Expect(driver.Paused("tst-006")).To(BeTrue())
+
+		// nclet restarts while hibernated, and forgets that it hibernated ProblemEnvironment
+		end = sessions.Begin("tst-006")
+		defer end()
+		sessions.SetHibernated("tst-006", false)
+
+		Eventually(getProblemEnvironment("tst-006")).WithTimeout(10 * time.Second).Should(
+			WithTransform(hibernated, Equal("False/WokeUp")),
+		)
+		Expect(driver.Paused("tst-006")).To(BeFalse())
+		Eventually(getProblemEnvironment("tst-006")).WithTimeout(5 * time.Second).Should(
+			WithTransform(readiness, Equal([]bool{true, true})),
+		)
+	})
+})
@@ -0,0 +1,122 @@
+package controllers
+
+import (
+	"sync"
+	"time"
+)
+
+// SessionTracker tracks SSH sessions per ProblemEnvironment to find idle ones.
+// It's shared between SSHServer and ProblemEnvironmentReconciler for hibernation.
+type SessionTracker struct {
+	mu sync.Mutex
+
+	// startedAt is used as the last activity of ProblemEnvironments without sessions
+	// since nclet started, as sessions before restarting nclet are unknown
+	startedAt time.Time
+
+	sessions     map[string]int
+	lastActivity map[string]time.Time
+	locks        map[string]*sync.Mutex
+
+	// hibernated remembers ProblemEnvironments hibernated by this nclet,
+	// as the condition in the cache can be stale just after updated
+	hibernated map[string]bool
+
+	// now is replaced in tests
+	now func() time.Time
+}
+
+func NewSessionTracker() *SessionTracker {
+	return newSessionTrackerWithClock(time.Now)
+}
+
+func newSessionTrackerWithClock(now func() time.Time) *SessionTracker {
+	return &SessionTracker{
+		startedAt:    now(),
+		sessions:     map[string]int{},
+		lastActivity: map[string]time.Time{},
+		locks:        map[string]*sync.Mutex{},
+		hibernated:   map[string]bool{},
+		now:          now,
+	}
+}
+
+// Begin records the start of a session to the ProblemEnvironment.
+// The returned function must be called when the session ends.
+func (t *SessionTracker) Begin(name string) func() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.sessions[name]++
+	t.lastActivity[name] = t.now()
+
+	return func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+
+		t.sessions[name]--
+		t.lastActivity[name] = t.now()
+		if t.sessions[name] == 0 {
+			delete(t.sessions, name)
+		}
+	}
+}
+
+// IdleSince returns when the ProblemEnvironment became idle, or false if it has sessions.
+func (t *SessionTracker) IdleSince(name string) (time.Time, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.sessions[name] > 0 {
+		return time.Time{}, false
+	}
+	if lastActivity, ok := t.lastActivity[name]; ok {
+		return lastActivity, true
+	}
+	return t.startedAt, true
+}
+
+// Lock serializes hibernating and waking up the ProblemEnvironment.
+// The returned function unlocks it.
+func (t *SessionTracker) Lock(name string) func() {
+	t.mu.Lock()
+	lock, ok := t.locks[name]
+	if !ok {
+		lock = &sync.Mutex{}
+		t.locks[name] = lock
+	}
+	t.mu.Unlock()
+
+	lock.Lock()
+	return lock.Unlock
+}
+
+// SetHibernated records whether the ProblemEnvironment is hibernated. It must be called with Lock held.
+func (t *SessionTracker) SetHibernated(name string, hibernated bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if hibernated {
+		t.hibernated[name] = true
+	} else {
+		delete(t.hibernated, name)
+	}
+}
+
+// Hibernated returns true if the ProblemEnvironment was hibernated by this nclet and not woken up yet.
+func (t *SessionTracker) Hibernated(name string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.hibernated[name]
+}
+
+// Forget drops everything about the ProblemEnvironment deleted.
+func (t *SessionTracker) Forget(name string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.lastActivity, name)
+	delete(t.locks, name)
+	delete(t.hibernated, name)
+}
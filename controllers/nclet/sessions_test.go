@@ -0,0 +1,216 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	netconv1alpha1 "github.com/janog-netcon/netcon-problem-management-subsystem/api/v1alpha1"
+	"github.com/janog-netcon/netcon-problem-management-subsystem/pkg/util"
+)
+
+// fakeClock is the clock injected to SessionTracker.
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func TestSessionTrackerIdleSince(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	startedAt := clock.Now()
+	tracker := newSessionTrackerWithClock(clock.Now)
+
+	// sessions before nclet started are unknown
+	if idleSince, ok := tracker.IdleSince("tst-001"); !ok || !idleSince.Equal(startedAt) {
+		t.Errorf("ProblemEnvironment without sessions should be idle since nclet started: %v, %v", idleSince, ok)
+	}
+
+	clock.Advance(time.Minute)
+	end1 := tracker.Begin("tst-001")
+	clock.Advance(time.Minute)
+	end2 := tracker.Begin("tst-001")
+
+	if _, ok := tracker.IdleSince("tst-001"); ok {
+		t.Errorf("ProblemEnvironment with sessions should not be idle")
+	}
+	if _, ok := tracker.IdleSince("tst-002"); !ok {
+		t.Errorf("sessions should be tracked per ProblemEnvironment")
+	}
+
+	clock.Advance(time.Minute)
+	end1()
+	if _, ok := tracker.IdleSince("tst-001"); ok {
+		t.Errorf("ProblemEnvironment with a remaining session should not be idle")
+	}
+
+	clock.Advance(time.Minute)
+	endedAt := clock.Now()
+	end2()
+
+	clock.Advance(time.Hour)
+	if idleSince, ok := tracker.IdleSince("tst-001"); !ok || !idleSince.Equal(endedAt) {
+		t.Errorf("ProblemEnvironment should be idle since the last session ended: %v, %v", idleSince, ok)
+	}
+
+	tracker.Forget("tst-001")
+	if idleSince, ok := tracker.IdleSince("tst-001"); !ok || !idleSince.Equal(startedAt) {
+		t.Errorf("the last activity should be forgotten: %v, %v", idleSince, ok)
+	}
+}
+
+func TestSessionTrackerHibernated(t *testing.T) {
+	tracker := NewSessionTracker()
+
+	if tracker.Hibernated("tst-001") {
+		t.Errorf("ProblemEnvironment should not be hibernated initially")
+	}
+
+	tracker.SetHibernated("tst-001", true)
+	if !tracker.Hibernated("tst-001") || tracker.Hibernated("tst-002") {
+		t.Errorf("only tst-001 should be hibernated")
+	}
+
+	tracker.SetHibernated("tst-001", false)
+	if tracker.Hibernated("tst-001") {
+		t.Errorf("tst-001 should be woken up")
+	}
+
+	tracker.SetHibernated("tst-001", true)
+	tracker.Forget("tst-001")
+	if tracker.Hibernated("tst-001") {
+		t.Errorf("tst-001 should be forgotten")
+	}
+}
+
+func TestSessionTrackerLock(t *testing.T) {
+	tracker := NewSessionTracker()
+
+	unlock := tracker.Lock("tst-001")
+
+	// ProblemEnvironments are locked separately
+	tracker.Lock("tst-002")()
+
+	locked := make(chan struct{})
+	go func() {
+		defer close(locked)
+		tracker.Lock("tst-001")()
+	}()
+
+	select {
+	case <-locked:
+		t.Fatalf("tst-001 should be locked until unlocked")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	unlock()
+
+	select {
+	case <-locked:
+	case <-time.After(time.Second):
+		t.Fatalf("tst-001 should be lockable again after unlocked")
+	}
+}
+
+func TestProblemEnvironmentReconcilerIsIdle(t *testing.T) {
+	newProblemEnvironment := func(hibernateAfter time.Duration, assignedAgo time.Duration) *netconv1alpha1.ProblemEnvironment {
+		problemEnvironment := &netconv1alpha1.ProblemEnvironment{}
+		problemEnvironment.Name = "tst-001"
+		if hibernateAfter > 0 {
+			problemEnvironment.Spec.HibernateAfter = &metav1.Duration{Duration: hibernateAfter}
+		}
+		if assignedAgo > 0 {
+			util.SetProblemEnvironmentCondition(
+				problemEnvironment,
+				netconv1alpha1.ProblemEnvironmentConditionAssigned,
+				metav1.ConditionTrue,
+				"Test", "test",
+			)
+			condition := util.FindProblemEnvironmentCondition(
+				problemEnvironment,
+				netconv1alpha1.ProblemEnvironmentConditionAssigned,
+			)
+			condition.LastTransitionTime = metav1.NewTime(time.Now().Add(-assignedAgo))
+		}
+		return problemEnvironment
+	}
+
+	tests := []struct {
+		name           string
+		hibernateAfter time.Duration
+		assignedAgo    time.Duration
+		// lastActivityAgo is the period since the last session ended, or 0 without sessions
+		lastActivityAgo time.Duration
+		inSession       bool
+		want            bool
+	}{
+		{
+			name:            "idle longer than HibernateAfter",
+			hibernateAfter:  time.Hour,
+			assignedAgo:     3 * time.Hour,
+			lastActivityAgo: 2 * time.Hour,
+			want:            true,
+		},
+		{
+			name:            "idle shorter than HibernateAfter",
+			hibernateAfter:  time.Hour,
+			assignedAgo:     3 * time.Hour,
+			lastActivityAgo: 30 * time.Minute,
+			want:            false,
+		},
+		{
+			name:            "in session",
+			hibernateAfter:  time.Hour,
+			assignedAgo:     3 * time.Hour,
+			lastActivityAgo: 2 * time.Hour,
+			inSession:       true,
+			want:            false,
+		},
+		{
+			name:            "sessions before assigned are ignored",
+			hibernateAfter:  time.Hour,
+			assignedAgo:     10 * time.Minute,
+			lastActivityAgo: 2 * time.Hour,
+			want:            false,
+		},
+		{
+			name:            "without HibernateAfter",
+			assignedAgo:     3 * time.Hour,
+			lastActivityAgo: 2 * time.Hour,
+			want:            false,
+		},
+		{
+			name:            "not assigned",
+			hibernateAfter:  time.Hour,
+			lastActivityAgo: 2 * time.Hour,
+			want:            false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := &fakeClock{now: time.Now().Add(-4 * time.Hour)}
+			tracker := newSessionTrackerWithClock(clock.Now)
+
+			if tt.lastActivityAgo > 0 {
+				clock.now = time.Now().Add(-tt.lastActivityAgo)
+				end := tracker.Begin("tst-001")
+				if !tt.inSession {
+					end()
+				}
+			}
+
+			r := &ProblemEnvironmentReconciler{Sessions: tracker}
+			if got := r.isIdle(newProblemEnvironment(tt.hibernateAfter, tt.assignedAgo)); got != tt.want {
+				t.Errorf("unexpected idleness: got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
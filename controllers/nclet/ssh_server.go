@@ -19,8 +19,11 @@ import (
 	"github.com/creack/pty"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/codes"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
 
 	"github.com/pkg/errors"
 	gossh "golang.org/x/crypto/ssh"
@@ -30,6 +33,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 
 	netconv1alpha1 "github.com/janog-netcon/netcon-problem-management-subsystem/api/v1alpha1"
+	"github.com/janog-netcon/netcon-problem-management-subsystem/controllers/nclet/drivers"
 	"github.com/janog-netcon/netcon-problem-management-subsystem/internal/ssh"
 	"github.com/janog-netcon/netcon-problem-management-subsystem/internal/tracing"
 	"github.com/janog-netcon/netcon-problem-management-subsystem/pkg/util"
@@ -79,16 +83,33 @@ func parseUser(user string) (*User, error) {
 type SSHServer struct {
 	client.Client
 
+	recorder record.EventRecorder
+
 	sshAddr string
 
 	adminPassword string
+
+	// sessions tracks SSH sessions to wake up ProblemEnvironments hibernated
+	sessions *SessionTracker
+
+	driver drivers.ProblemEnvironmentDriver
 }
 
-func NewSSHServer(client client.Client, sshAddr string, adminPassword string) *SSHServer {
+func NewSSHServer(
+	client client.Client,
+	recorder record.EventRecorder,
+	sshAddr string,
+	adminPassword string,
+	sessions *SessionTracker,
+	driver drivers.ProblemEnvironmentDriver,
+) *SSHServer {
 	return &SSHServer{
 		Client:        client,
+		recorder:      recorder,
 		sshAddr:       sshAddr,
 		adminPassword: adminPassword,
+		sessions:      sessions,
+		driver:        driver,
 	}
 }
 
@@ -210,6 +231,13 @@ func (r *SSHServer) handle(ctx context.Context, s ssh.Session) error {
 		return tracing.GenerateError(span, "invalid user format")
 	}
 
+	end := r.sessions.Begin(user.ProblemEnvironmentName)
+	defer end()
+
+	if err := r.wakeUp(ctx, s, user.ProblemEnvironmentName); err != nil {
+		return tracing.WrapError(span, err, "failed to wake up problem environment")
+	}
+
 	topologyFilePath := path.Join("data", user.ProblemEnvironmentName, "manifest.yml")
 
 	args := []string{"-t", topologyFilePath}
@@ -274,6 +302,54 @@ func (r *SSHServer) handle(ctx context.Context, s ssh.Session) error {
 	return nil
 }
 
+// wakeUp resumes the containers of ProblemEnvironment if it's hibernated.
+func (r *SSHServer) wakeUp(ctx context.Context, s ssh.Session, name string) error {
+	unlock := r.sessions.Lock(name)
+	defer unlock()
+
+	key := types.NamespacedName{Namespace: "netcon", Name: name}
+
+	problemEnvironment := netconv1alpha1.ProblemEnvironment{}
+	if err := r.Get(ctx, key, &problemEnvironment); err != nil {
+		return err
+	}
+
+	if !r.sessions.Hibernated(name) && util.GetProblemEnvironmentCondition(
+		&problemEnvironment,
+		netconv1alpha1.ProblemEnvironmentConditionHibernated,
+	) != metav1.ConditionTrue {
+		return nil
+	}
+
+	fmt.Fprintf(s, "Waking up your lab, please wait a moment...\r\n")
+
+	if err := r.driver.WakeUp(ctx, r.Client, problemEnvironment); err != nil {
+		return err
+	}
+	r.sessions.SetHibernated(name, false)
+
+	r.recorder.Event(
+		&problemEnvironment,
+		corev1.EventTypeNormal,
+		netconv1alpha1.ProblemEnvironmentEventWokeUp,
+		"Containers are resumed on SSH login",
+	)
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if err := r.Get(ctx, key, &problemEnvironment); err != nil {
+			return err
+		}
+		util.SetProblemEnvironmentCondition(
+			&problemEnvironment,
+			netconv1alpha1.ProblemEnvironmentConditionHibernated,
+			metav1.ConditionFalse,
+			"WokeUp",
+			"Containers are resumed on SSH login",
+		)
+		return r.Status().Update(ctx, &problemEnvironment)
+	})
+}
+
 func (r *SSHServer) Start(ctx context.Context) error {
 	_ = log.FromContext(ctx)
 
@@ -8,6 +8,7 @@ import (
 	"os/exec"
 	"path"
 	"path/filepath"
+	"time"
 
 	"github.com/pkg/errors"
 	"gopkg.in/yaml.v3"
@@ -15,6 +16,15 @@ import (
 	netconv1alpha1 "github.com/janog-netcon/netcon-problem-management-subsystem/api/v1alpha1"
 )
 
+// BaseDirectoryPath is the directory where the working directory of each ProblemEnvironment is placed
+const BaseDirectoryPath = "data"
+
+// LabelLabName is the label ContainerLab adds to containers with the name of the lab
+const LabelLabName = "containerlab"
+
+// deployWaitDelay is the period to wait for the output after clab deploy is killed
+const deployWaitDelay = 10 * time.Second
+
 type ContainerLabClient struct {
 	workingDirectoryPath string
 	topologyFileName     string
@@ -37,7 +47,7 @@ func NewContainerLabClientFor(
 		return nil
 	}
 
-	topologyFilePath := path.Join("data", problemEnvironment.Name, "manifest.yml")
+	topologyFilePath := path.Join(BaseDirectoryPath, problemEnvironment.Name, "manifest.yml")
 	return NewContainerLabClient(topologyFilePath)
 }
 
@@ -95,6 +105,10 @@ func (c *ContainerLabClient) DeployWithOutput(ctx context.Context) ([]byte, []by
 	cmd.Stderr = &stderrBuffer
 	cmd.Dir = c.workingDirectoryPath
 
+	// clab is killed when ctx is done. WaitDelay prevents Run from waiting forever
+	// for the output of processes spawned by clab, which may be still alive.
+	cmd.WaitDelay = deployWaitDelay
+
 	err := cmd.Run()
 	return stdoutBuffer.Bytes(), stderrBuffer.Bytes(), err
 }
@@ -114,6 +128,23 @@ func (c *ContainerLabClient) Destroy(ctx context.Context) error {
 	return nil
 }
 
+// CreateVeth creates the veth pair between the endpoints like `clab-lab-node1:eth1`.
+// It's used to recreate the links of the node whose container was restarted.
+func (c *ContainerLabClient) CreateVeth(ctx context.Context, a, b string) error {
+	cmd := exec.CommandContext(ctx,
+		"clab",
+		"--log-level", "debug", "tools", "veth", "create", "-a", a, "-b", b,
+	)
+	cmd.Dir = c.workingDirectoryPath
+	cmd.Stderr = nil
+
+	if err := cmd.Run(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
 func (c *ContainerLabClient) Inspect(ctx context.Context) ([]ContainerDetails, error) {
 	cmd := exec.CommandContext(ctx,
 		"clab",
@@ -0,0 +1,97 @@
+package containerlab
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/docker/go-units"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// kindOf returns the kind definition applied to the node.
+// As well as containerlab, the kind of the node falls back to the one in defaults.
+func (t *Topology) kindOf(node *NodeDefinition) *NodeDefinition {
+	kind := node.Kind
+	if kind == "" && t.Defaults != nil {
+		kind = t.Defaults.Kind
+	}
+	return t.Kinds[kind]
+}
+
+// resolve returns the value of the field in the order of node, kind and defaults.
+// A node without any field, like `r1:` in YAML, is nil, and it inherits all fields.
+func resolve[T comparable](t *Topology, node *NodeDefinition, field func(*NodeDefinition) T) T {
+	var zero T
+
+	if node == nil {
+		node = &NodeDefinition{}
+	}
+
+	if v := field(node); v != zero {
+		return v
+	}
+	if kind := t.kindOf(node); kind != nil {
+		if v := field(kind); v != zero {
+			return v
+		}
+	}
+	if t.Defaults != nil {
+		return field(t.Defaults)
+	}
+	return zero
+}
+
+// NodeCPU returns the CPU of the node inherited from kinds and defaults.
+func (t *Topology) NodeCPU(node *NodeDefinition) float64 {
+	return resolve(t, node, func(n *NodeDefinition) float64 { return n.CPU })
+}
+
+// NodeMemory returns the memory of the node inherited from kinds and defaults.
+func (t *Topology) NodeMemory(node *NodeDefinition) string {
+	return resolve(t, node, func(n *NodeDefinition) string { return n.Memory })
+}
+
+// NodeImage returns the image of the node inherited from kinds and defaults.
+func (t *Topology) NodeImage(node *NodeDefinition) string {
+	return resolve(t, node, func(n *NodeDefinition) string { return n.Image })
+}
+
+// Images returns the images used by the nodes in the topology without duplicates.
+func (t *Topology) Images() []string {
+	seen := map[string]bool{}
+	images := []string{}
+	for _, node := range t.Nodes {
+		image := t.NodeImage(node)
+		if image == "" || seen[image] {
+			continue
+		}
+		seen[image] = true
+		images = append(images, image)
+	}
+	sort.Strings(images)
+	return images
+}
+
+// ResourceRequests returns the sum of CPU and memory of all nodes in the topology.
+// Nodes without CPU or memory are considered to request nothing.
+func (t *Topology) ResourceRequests() (corev1.ResourceList, error) {
+	milliCPU, memory := int64(0), int64(0)
+
+	for name, node := range t.Nodes {
+		milliCPU += int64(t.NodeCPU(node) * 1000)
+
+		if value := t.NodeMemory(node); value != "" {
+			bytes, err := units.RAMInBytes(value)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse memory of node %s: %w", name, err)
+			}
+			memory += bytes
+		}
+	}
+
+	return corev1.ResourceList{
+		corev1.ResourceCPU:    *resource.NewMilliQuantity(milliCPU, resource.DecimalSI),
+		corev1.ResourceMemory: *resource.NewQuantity(memory, resource.BinarySI),
+	}, nil
+}
@@ -0,0 +1,112 @@
+package containerlab
+
+import (
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestTopologyResourceRequests(t *testing.T) {
+	tests := []struct {
+		name       string
+		topology   string
+		wantCPU    string
+		wantMemory string
+		wantImages []string
+		wantErr    bool
+	}{
+		{
+			name: "inherited from kinds and defaults",
+			topology: `
+defaults:
+  kind: linux
+  memory: 512MB
+kinds:
+  ceos:
+    image: ceos:4.28.0F
+    cpu: 2
+    memory: 2GB
+  linux:
+    image: alpine:latest
+nodes:
+  r1:
+    kind: ceos
+  r2:
+    kind: ceos
+    cpu: 1
+  host:
+    cpu: 0.5
+`,
+			wantCPU:    "3500m",
+			wantMemory: "4608Mi",
+			wantImages: []string{"alpine:latest", "ceos:4.28.0F"},
+		},
+		{
+			name: "node without fields",
+			topology: `
+defaults:
+  kind: linux
+kinds:
+  linux:
+    image: alpine:latest
+    cpu: 1
+    memory: 1GB
+nodes:
+  host1:
+  host2:
+`,
+			wantCPU:    "2",
+			wantMemory: "2Gi",
+			wantImages: []string{"alpine:latest"},
+		},
+		{
+			name: "node without fields nor defaults",
+			topology: `
+nodes:
+  host1:
+`,
+			wantCPU:    "0",
+			wantMemory: "0",
+			wantImages: []string{},
+		},
+		{
+			name: "invalid memory",
+			topology: `
+nodes:
+  host1:
+    memory: foo
+`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			topology := Topology{}
+			if err := yaml.Unmarshal([]byte(tt.topology), &topology); err != nil {
+				t.Fatal(err)
+			}
+
+			requests, err := topology.ResourceRequests()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if cpu := requests[corev1.ResourceCPU]; cpu.Cmp(resource.MustParse(tt.wantCPU)) != 0 {
+				t.Errorf("unexpected CPU: got %s, want %s", cpu.String(), tt.wantCPU)
+			}
+			if memory := requests[corev1.ResourceMemory]; memory.Cmp(resource.MustParse(tt.wantMemory)) != 0 {
+				t.Errorf("unexpected memory: got %s, want %s", memory.String(), tt.wantMemory)
+			}
+			if images := topology.Images(); !reflect.DeepEqual(images, tt.wantImages) {
+				t.Errorf("unexpected images: got %v, want %v", images, tt.wantImages)
+			}
+		})
+	}
+}
@@ -5,7 +5,7 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
-	"strings"
+	"strconv"
 	"time"
 
 	"github.com/janog-netcon/netcon-problem-management-subsystem/api/v1alpha1"
@@ -15,6 +15,7 @@ import (
 	"github.com/janog-netcon/netcon-problem-management-subsystem/pkg/util"
 	"github.com/spf13/cobra"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/kubernetes/scheme"
 )
 
@@ -33,6 +34,7 @@ func newProblemEnvironmentCmd() *cobra.Command {
 	cmd.AddCommand(newProblemEnvironmentDeleteCmd())
 	cmd.AddCommand(newProblemEnvironmentAssignCmd())
 	cmd.AddCommand(newProblemEnvironmentUnassignCmd())
+	cmd.AddCommand(newProblemEnvironmentResetCmd())
 	cmd.AddCommand(newProblemEnvironmentShowDeployLogCmd())
 	cmd.AddCommand(newProblemEnvironmentSSHCmd())
 
@@ -41,6 +43,8 @@ func newProblemEnvironmentCmd() *cobra.Command {
 
 func newProblemEnvironmentListCmd() *cobra.Command {
 	var verbose bool
+	var problemName string
+	var assignee string
 
 	cmd := &cobra.Command{
 		Use:          "list",
@@ -63,7 +67,17 @@ func newProblemEnvironmentListCmd() *cobra.Command {
 
 			client := clientset.ProblemEnvironment(*globalConfig.configFlags.Namespace)
 
-			problemEnvironmentList, err := client.List(ctx, metav1.ListOptions{})
+			selector := labels.Set{}
+			if problemName != "" {
+				selector[v1alpha1.LabelProblemName] = problemName
+			}
+			if assignee != "" {
+				selector[v1alpha1.LabelAssignee] = assignee
+			}
+
+			problemEnvironmentList, err := client.List(ctx, metav1.ListOptions{
+				LabelSelector: selector.String(),
+			})
 			if err != nil {
 				return err
 			}
@@ -84,6 +98,8 @@ func newProblemEnvironmentListCmd() *cobra.Command {
 	}
 
 	cmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Show more verbose log")
+	cmd.Flags().StringVarP(&problemName, "problem", "p", "", "Show ProblemEnvironments of the Problem only")
+	cmd.Flags().StringVarP(&assignee, "assignee", "a", "", "Show ProblemEnvironments assigned to the assignee only")
 
 	return cmd
 }
@@ -251,11 +267,20 @@ func newProblemEnvironmentUnassignCmd() *cobra.Command {
 				"AdminUpdated",
 				"assigned by admin forcibly",
 			)
+			problemEnvironment.Status.Assignee = nil
 
-			if _, err := client.UpdateStatus(ctx, problemEnvironment, metav1.UpdateOptions{}); err != nil {
+			problemEnvironment, err = client.UpdateStatus(ctx, problemEnvironment, metav1.UpdateOptions{})
+			if err != nil {
 				return err
 			}
 
+			if _, ok := problemEnvironment.Labels[v1alpha1.LabelAssignee]; ok {
+				delete(problemEnvironment.Labels, v1alpha1.LabelAssignee)
+				if _, err := client.Update(ctx, problemEnvironment, metav1.UpdateOptions{}); err != nil {
+					return err
+				}
+			}
+
 			fmt.Printf("ProblemEnvironment \"%s\" unassigned\n", name)
 
 			return nil
@@ -265,12 +290,70 @@ func newProblemEnvironmentUnassignCmd() *cobra.Command {
 	return cmd
 }
 
+func newProblemEnvironmentResetCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "reset",
+		Short:        "Destroy and redeploy ProblemEnvironment keeping its name and password",
+		SilenceUsage: true,
+		Args:         cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			name := args[0]
+
+			v1alpha1.AddToScheme(scheme.Scheme)
+
+			config, err := globalConfig.configFlags.ToRESTConfig()
+			if err != nil {
+				return err
+			}
+
+			clientset, err := clientset.NewForConfig(config)
+			if err != nil {
+				return err
+			}
+
+			client := clientset.ProblemEnvironment(*globalConfig.configFlags.Namespace)
+
+			problemEnvironment, err := client.Get(ctx, name, metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+
+			if problemEnvironment.DeletionTimestamp != nil {
+				return errors.New("failed to reset: ProblemEnvironment is being deleted")
+			}
+
+			if util.IsProblemEnvironmentResetting(problemEnvironment) {
+				return errors.New("failed to reset: ProblemEnvironment is already being reset")
+			}
+
+			util.RequestProblemEnvironmentReset(problemEnvironment)
+			if _, err := client.Update(ctx, problemEnvironment, metav1.UpdateOptions{}); err != nil {
+				return err
+			}
+
+			fmt.Printf("ProblemEnvironment \"%s\" is being reset\n", name)
+
+			return nil
+		},
+	}
+
+	return cmd
+}
+
 func newProblemEnvironmentShowDeployLogCmd() *cobra.Command {
 	var verbose bool
+	var attempt int
+	var resetGeneration int64
+	var list bool
 
 	cmd := &cobra.Command{
-		Use:          "show-deploy-log",
-		Short:        "Show deploy log for given ProblemEnvironment",
+		Use:   "show-deploy-log",
+		Short: "Show deploy log for given ProblemEnvironment",
+		Long: "Show the latest deploy log for given ProblemEnvironment.\n" +
+			"Use --attempt to show the specific attempt, and --list to list the deploy logs kept.\n" +
+			"As attempts are counted again after reset, --attempt shows the latest one among resets\n" +
+			"unless --reset-generation is set.",
 		SilenceUsage: true,
 		Args:         cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -291,11 +374,6 @@ func newProblemEnvironmentShowDeployLogCmd() *cobra.Command {
 				return err
 			}
 
-			problemEnvironmentClient, err := newProblemEnvironmentClientForConfig(config, namespace)
-			if err != nil {
-				return err
-			}
-
 			jst, err := time.LoadLocation("Asia/Tokyo")
 			if err != nil {
 				return err
@@ -308,36 +386,56 @@ func newProblemEnvironmentShowDeployLogCmd() *cobra.Command {
 				printer.SetLevel(deploylog.LogLevelDebug)
 			}
 
-			configMapList, err := configMapClient.List(ctx, metav1.ListOptions{})
-			if err != nil {
-				return err
+			selector := labels.Set{v1alpha1.LabelDeployLogFor: name}
+			if attempt > 0 {
+				selector[v1alpha1.LabelDeployAttempt] = strconv.Itoa(attempt)
+			}
+			if resetGeneration >= 0 {
+				selector[v1alpha1.LabelDeployResetGeneration] = strconv.FormatInt(resetGeneration, 10)
 			}
 
-			problemEnvironment, err := problemEnvironmentClient.Get(ctx, name, metav1.GetOptions{})
+			configMapList, err := configMapClient.List(ctx, metav1.ListOptions{
+				LabelSelector: selector.String(),
+			})
 			if err != nil {
 				return err
 			}
 
-			for _, configMap := range configMapList.Items {
-				if !strings.HasPrefix(configMap.Name, "deploy-"+problemEnvironment.Name) {
-					continue
-				}
+			configMaps := configMapList.Items
+			util.SortDeployLogs(configMaps)
 
-				stderr, ok := configMap.Data["stderr"]
-				if !ok {
-					continue
-				}
+			if list {
+				return printers.PrintDeployLogs(os.Stdout, configMaps, printers.PrintOptions{Wide: verbose})
+			}
 
-				log, err := parser.Parse([]byte(stderr))
-				if err != nil {
-					return err
+			if len(configMaps) == 0 {
+				if attempt > 0 {
+					return fmt.Errorf("deploy log for attempt %d of %s not found", attempt, name)
 				}
+				return fmt.Errorf("deploy log for %s not found", name)
+			}
+			configMap := configMaps[0]
 
-				if err := printer.Print(log); err != nil {
-					return err
-				}
+			stderr, ok := configMap.Data["stderr"]
+			if !ok {
+				return fmt.Errorf("stderr is missing in deploy log %s", configMap.Name)
+			}
+
+			log, err := parser.Parse([]byte(stderr))
+			if err != nil {
+				return err
+			}
 
-				break
+			if err := printer.Print(log); err != nil {
+				return err
+			}
+
+			// result is missing in the deploy logs recorded by old nclet
+			if result, ok := configMap.Data["result"]; ok {
+				fmt.Printf("Result: %s\n", result)
+				if deployErr, ok := configMap.Data["error"]; ok {
+					fmt.Printf("Error: %s\n", deployErr)
+				}
 			}
 
 			return nil
@@ -345,6 +443,12 @@ func newProblemEnvironmentShowDeployLogCmd() *cobra.Command {
 	}
 
 	cmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Show more verbose log")
+	cmd.Flags().IntVar(&attempt, "attempt", 0,
+		"Show the deploy log of the attempt instead of the latest one. "+
+			"The latest one among resets is shown if the attempt was made several times")
+	cmd.Flags().Int64Var(&resetGeneration, "reset-generation", -1,
+		"Show the deploy logs recorded after the reset of the generation. A negative value means all")
+	cmd.Flags().BoolVarP(&list, "list", "l", false, "List the deploy logs kept for ProblemEnvironment")
 
 	return cmd
 }
@@ -28,6 +28,7 @@ func NewRootCmd() *cobra.Command {
 
 	cmd.AddCommand(newProblemEnvironmentCmd())
 	cmd.AddCommand(newWorkerCmd())
+	cmd.AddCommand(newScheduleCmd())
 
 	return cmd
 }
@@ -0,0 +1,303 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/janog-netcon/netcon-problem-management-subsystem/api/v1alpha1"
+	clientset "github.com/janog-netcon/netcon-problem-management-subsystem/pkg/clientset/v1alpha1"
+	"github.com/janog-netcon/netcon-problem-management-subsystem/pkg/containerlab"
+	"github.com/janog-netcon/netcon-problem-management-subsystem/pkg/printers"
+	"github.com/janog-netcon/netcon-problem-management-subsystem/pkg/scheduler"
+	"github.com/janog-netcon/netcon-problem-management-subsystem/pkg/util"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+func newScheduleCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "schedule",
+		Short:        "Scheduler",
+		SilenceUsage: true,
+	}
+
+	cmd.AddCommand(newScheduleSimulateCmd())
+
+	return cmd
+}
+
+// countAssignable counts ProblemEnvironments of the Problem in the same way as ProblemReconciler.
+func countAssignable(problemEnvironments []v1alpha1.ProblemEnvironment, problem *v1alpha1.Problem) int {
+	assignable := 0
+	for i := range problemEnvironments {
+		pe := &problemEnvironments[i]
+		if pe.Namespace != problem.Namespace || pe.Labels[v1alpha1.LabelProblemName] != problem.Name || pe.DeletionTimestamp != nil {
+			continue
+		}
+		if util.GetProblemEnvironmentCondition(pe, v1alpha1.ProblemEnvironmentConditionAssigned) == metav1.ConditionTrue {
+			continue
+		}
+		// ProblemEnvironments nclet gave up deploying are replaced
+		if util.GetProblemEnvironmentCondition(pe, v1alpha1.ProblemEnvironmentConditionFailed) == metav1.ConditionTrue {
+			continue
+		}
+		assignable++
+	}
+	return assignable
+}
+
+// topologyLoader loads topologies referred by ProblemEnvironments, caching them
+// and the errors by ConfigMap.
+type topologyLoader struct {
+	client     corev1client.ConfigMapInterface
+	topologies map[v1alpha1.ConfigMapFileSource]*containerlab.Topology
+	errors     map[v1alpha1.ConfigMapFileSource]error
+}
+
+func (l *topologyLoader) load(ctx context.Context, problemEnvironment *v1alpha1.ProblemEnvironment) (*containerlab.Topology, error) {
+	ref := problemEnvironment.Spec.TopologyFile.ConfigMapRef
+	if topology, ok := l.topologies[ref]; ok {
+		return topology, nil
+	}
+	if err, ok := l.errors[ref]; ok {
+		return nil, err
+	}
+
+	configMap, err := l.client.Get(ctx, ref.Name, metav1.GetOptions{})
+	if err != nil {
+		l.errors[ref] = err
+		return nil, err
+	}
+
+	data, ok := configMap.Data[ref.Key]
+	if !ok {
+		l.errors[ref] = fmt.Errorf("ConfigMap %s found, but key `%s` missing", ref.Name, ref.Key)
+		return nil, l.errors[ref]
+	}
+
+	config := containerlab.Config{}
+	if err := yaml.Unmarshal([]byte(data), &config); err != nil {
+		l.errors[ref] = err
+		return nil, err
+	}
+
+	l.topologies[ref] = &config.Topology
+	return &config.Topology, nil
+}
+
+func newScheduleSimulateCmd() *cobra.Command {
+	var verbose bool
+	var replicas map[string]int
+	var seed uint64
+	var schedulerConfigPath string
+	var schedulerParameters scheduler.DefaultParameters
+
+	cmd := &cobra.Command{
+		Use:   "simulate",
+		Short: "Simulate where ProblemEnvironments will be scheduled",
+		Long: "Simulate where ProblemEnvironments will be scheduled when assignableReplicas of Problems are met.\n" +
+			"It runs the scheduler against the current Workers and ProblemEnvironments, but creates nothing.\n" +
+			"Only Problems in the namespace are simulated, while ProblemEnvironments in all namespaces occupy Workers.",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			namespace := *globalConfig.configFlags.Namespace
+
+			v1alpha1.AddToScheme(scheme.Scheme)
+
+			config, err := globalConfig.configFlags.ToRESTConfig()
+			if err != nil {
+				return err
+			}
+
+			clientset, err := clientset.NewForConfig(config)
+			if err != nil {
+				return err
+			}
+
+			configMapClient, err := newConfigMapClientForConfig(config, namespace)
+			if err != nil {
+				return err
+			}
+
+			schedulerConfig := scheduler.NewDefaultConfig(schedulerParameters)
+			if schedulerConfigPath != "" {
+				schedulerConfig, err = scheduler.LoadConfig(schedulerConfigPath)
+				if err != nil {
+					return err
+				}
+			}
+
+			framework, err := scheduler.NewFramework(
+				schedulerConfig,
+				scheduler.NewInTreeRegistry(),
+				scheduler.WithRandomSource(scheduler.NewSeededRandomSource(seed)),
+			)
+			if err != nil {
+				return err
+			}
+
+			workers, err := clientset.Worker().List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return err
+			}
+
+			problems, err := clientset.Problem(namespace).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return err
+			}
+			sort.Slice(problems.Items, func(i, j int) bool {
+				return problems.Items[i].Name < problems.Items[j].Name
+			})
+
+			// ProblemEnvironments in all namespaces share Workers, as scheduled by controller-manager
+			problemEnvironments, err := clientset.ProblemEnvironment(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return err
+			}
+
+			for name := range replicas {
+				found := false
+				for _, problem := range problems.Items {
+					found = found || problem.Name == name
+				}
+				if !found {
+					return fmt.Errorf("failed to simulate: Problem \"%s\" not found", name)
+				}
+			}
+
+			loader := &topologyLoader{
+				client:     configMapClient,
+				topologies: map[v1alpha1.ConfigMapFileSource]*containerlab.Topology{},
+				errors:     map[v1alpha1.ConfigMapFileSource]error{},
+			}
+
+			// As well as ProblemEnvironmentReconciler, ProblemEnvironments are scheduled
+			// without the topology or resource requests if they are unavailable.
+			warned := map[v1alpha1.ConfigMapFileSource]bool{}
+			warn := func(problemEnvironment *v1alpha1.ProblemEnvironment, format string, err error) {
+				ref := problemEnvironment.Spec.TopologyFile.ConfigMapRef
+				if warned[ref] {
+					return
+				}
+				warned[ref] = true
+				fmt.Fprintf(os.Stderr, "warning: "+format+", simulating without it: %s\n", ref.Name, err)
+			}
+			withResourceRequests := func(problemEnvironment *v1alpha1.ProblemEnvironment) {
+				if problemEnvironment.Status.ResourceRequests != nil {
+					return
+				}
+				topology, err := loader.load(ctx, problemEnvironment)
+				if err != nil {
+					warn(problemEnvironment, "failed to load topology from ConfigMap %s", err)
+					return
+				}
+				resourceRequests, err := topology.ResourceRequests()
+				if err != nil {
+					warn(problemEnvironment, "failed to calculate resource requests of ConfigMap %s", err)
+					return
+				}
+				problemEnvironment.Status.ResourceRequests = resourceRequests
+			}
+
+			// ProblemEnvironments not scheduled yet in the namespace are scheduled first
+			pending := []v1alpha1.ProblemEnvironment{}
+			for i := range problemEnvironments.Items {
+				pe := &problemEnvironments.Items[i]
+				if pe.Namespace != namespace || pe.Spec.WorkerName != "" || pe.DeletionTimestamp != nil {
+					continue
+				}
+				withResourceRequests(pe)
+				pending = append(pending, *pe)
+			}
+
+			// ProblemEnvironments to be created are scheduled in turn across Problems.
+			// ProblemReconciler creates them in rate-limited batches while Problems are
+			// reconciled concurrently, so the actual order may differ from the simulation.
+			toCreate := map[string]int{}
+			for i := range problems.Items {
+				problem := &problems.Items[i]
+				desired := util.GetAssignableReplicas(problem)
+				if allocated := problem.Status.AllocatedReplicas; allocated != nil {
+					desired = min(desired, *allocated)
+				}
+				if override, ok := replicas[problem.Name]; ok {
+					desired = override
+				}
+				toCreate[problem.Name] = desired - countAssignable(problemEnvironments.Items, problem)
+			}
+
+			for n := 1; ; n++ {
+				created := false
+				for i := range problems.Items {
+					problem := &problems.Items[i]
+					if toCreate[problem.Name] < n || problem.Spec.Template == nil {
+						continue
+					}
+
+					template := problem.Spec.Template.DeepCopy()
+					pe := v1alpha1.ProblemEnvironment{}
+					pe.Labels = template.Labels
+					pe.Annotations = template.Annotations
+					pe.Namespace = problem.Namespace
+					pe.Name = fmt.Sprintf("%s-simulated-%d", problem.Name, n)
+					pe.Spec = template.Spec
+
+					if pe.Labels == nil {
+						pe.Labels = map[string]string{}
+					}
+					pe.Labels[v1alpha1.LabelProblemName] = problem.Name
+
+					withResourceRequests(&pe)
+					pending = append(pending, pe)
+					created = true
+				}
+				if !created {
+					break
+				}
+			}
+
+			simulator := scheduler.NewSimulator(framework, scheduler.CycleState{
+				Workers:             workers.Items,
+				ProblemEnvironments: problemEnvironments.Items,
+				Problems:            problems.Items,
+			})
+			placements := simulator.Simulate(ctx, pending, func(pe *v1alpha1.ProblemEnvironment) *containerlab.Topology {
+				// topologies are always loaded when calculating resource requests,
+				// and it's nil if loading failed
+				return loader.topologies[pe.Spec.TopologyFile.ConfigMapRef]
+			})
+
+			printOptions := printers.PrintOptions{}
+			if verbose {
+				printOptions.Wide = true
+			}
+
+			return printers.PrintSimulation(os.Stdout, simulator.State(), placements, printOptions)
+		},
+	}
+
+	cmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Show placement of all ProblemEnvironments")
+	cmd.Flags().StringToIntVar(&replicas, "replicas", map[string]int{},
+		"Override assignableReplicas of Problems (e.g. --replicas pro-001=10,pro-002=5)")
+	cmd.Flags().Uint64Var(&seed, "seed", 0, "Seed for the random election of Workers")
+	// the flags below are the same as the ones of controller-manager
+	cmd.Flags().Float64Var(&schedulerParameters.CPUWeight, "cpu-weight", 1.0, "The weight of CPU usage.")
+	cmd.Flags().Float64Var(&schedulerParameters.MemoryWeight, "memory-weight", 3.0, "The weight of memory usage.")
+	cmd.Flags().Float64Var(&schedulerParameters.MemoryThreshold, "memory-threshold", 90.0, "The threshold of memory usage.")
+	cmd.Flags().Float64Var(&schedulerParameters.Temperature, "temperature", 0.1,
+		"The temperature of the Boltzmann distribution.")
+	cmd.Flags().IntVar(&schedulerParameters.MaxPendingDeployments, "max-pending-deployments", 0,
+		"The maximum number of ProblemEnvironments being deployed on each Worker. 0 means unlimited.")
+	cmd.Flags().StringVar(&schedulerConfigPath, "scheduler-config", "",
+		"The path to the scheduler config file used by controller-manager. "+
+			"If set, --cpu-weight, --memory-weight, --memory-threshold, --temperature "+
+			"and --max-pending-deployments are ignored.")
+
+	return cmd
+}
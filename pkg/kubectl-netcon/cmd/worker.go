@@ -4,11 +4,13 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/janog-netcon/netcon-problem-management-subsystem/api/v1alpha1"
 	clientset "github.com/janog-netcon/netcon-problem-management-subsystem/pkg/clientset/v1alpha1"
 	"github.com/janog-netcon/netcon-problem-management-subsystem/pkg/printers"
 	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes/scheme"
 )
@@ -26,6 +28,8 @@ func newWorkerCmd() *cobra.Command {
 	cmd.AddCommand(newWorkerListCmd())
 	cmd.AddCommand(newWorkerEnableCmd())
 	cmd.AddCommand(newWorkerDisableCmd())
+	cmd.AddCommand(newWorkerTaintCmd())
+	cmd.AddCommand(newWorkerUntaintCmd())
 
 	return cmd
 }
@@ -173,3 +177,161 @@ func newWorkerDisableCmd() *cobra.Command {
 
 	return cmd
 }
+
+// parseTaint parses taint in the form of `key[=value]:effect`.
+func parseTaint(spec string) (corev1.Taint, error) {
+	taint := corev1.Taint{}
+
+	keyValue, effect, ok := strings.Cut(spec, ":")
+	if !ok {
+		return taint, fmt.Errorf("invalid taint %q: effect is required", spec)
+	}
+
+	switch corev1.TaintEffect(effect) {
+	case corev1.TaintEffectNoSchedule, corev1.TaintEffectPreferNoSchedule, corev1.TaintEffectNoExecute:
+	default:
+		return taint, fmt.Errorf("invalid taint %q: unknown effect %q", spec, effect)
+	}
+
+	key, value, _ := strings.Cut(keyValue, "=")
+	if key == "" {
+		return taint, fmt.Errorf("invalid taint %q: key is required", spec)
+	}
+
+	taint.Key = key
+	taint.Value = value
+	taint.Effect = corev1.TaintEffect(effect)
+	return taint, nil
+}
+
+func newWorkerTaintCmd() *cobra.Command {
+	var overwrite bool
+
+	cmd := &cobra.Command{
+		Use:          "taint NAME KEY[=VALUE]:EFFECT...",
+		Short:        "Add taints to Worker",
+		SilenceUsage: true,
+		Args:         cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			name := args[0]
+
+			taints := []corev1.Taint{}
+			for _, spec := range args[1:] {
+				taint, err := parseTaint(spec)
+				if err != nil {
+					return err
+				}
+				taints = append(taints, taint)
+			}
+
+			v1alpha1.AddToScheme(scheme.Scheme)
+
+			config, err := globalConfig.configFlags.ToRESTConfig()
+			if err != nil {
+				return err
+			}
+
+			clientset, err := clientset.NewForConfig(config)
+			if err != nil {
+				return err
+			}
+
+			client := clientset.Worker()
+
+			worker, err := client.Get(ctx, name, metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+
+		TAINTS:
+			for _, taint := range taints {
+				for i := range worker.Spec.Taints {
+					if !worker.Spec.Taints[i].MatchTaint(&taint) {
+						continue
+					}
+					if !overwrite {
+						return fmt.Errorf(
+							"failed to taint Worker: Worker already has taint with key %q and effect %q, use --overwrite",
+							taint.Key, taint.Effect,
+						)
+					}
+					worker.Spec.Taints[i].Value = taint.Value
+					continue TAINTS
+				}
+				worker.Spec.Taints = append(worker.Spec.Taints, taint)
+			}
+
+			if _, err := client.Update(ctx, worker, metav1.UpdateOptions{}); err != nil {
+				return err
+			}
+
+			fmt.Printf("Worker \"%s\" tainted\n", name)
+
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&overwrite, "overwrite", false, "Overwrite the value of existing taints")
+
+	return cmd
+}
+
+func newWorkerUntaintCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "untaint NAME KEY[:EFFECT]...",
+		Short:        "Remove taints from Worker",
+		SilenceUsage: true,
+		Args:         cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			name := args[0]
+
+			v1alpha1.AddToScheme(scheme.Scheme)
+
+			config, err := globalConfig.configFlags.ToRESTConfig()
+			if err != nil {
+				return err
+			}
+
+			clientset, err := clientset.NewForConfig(config)
+			if err != nil {
+				return err
+			}
+
+			client := clientset.Worker()
+
+			worker, err := client.Get(ctx, name, metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+
+			for _, spec := range args[1:] {
+				key, effect, _ := strings.Cut(spec, ":")
+
+				taints := []corev1.Taint{}
+				for _, taint := range worker.Spec.Taints {
+					if taint.Key == key && (effect == "" || string(taint.Effect) == effect) {
+						continue
+					}
+					taints = append(taints, taint)
+				}
+
+				if len(taints) == len(worker.Spec.Taints) {
+					return fmt.Errorf("failed to untaint Worker: taint %q not found", spec)
+				}
+				worker.Spec.Taints = taints
+			}
+
+			if _, err := client.Update(ctx, worker, metav1.UpdateOptions{}); err != nil {
+				return err
+			}
+
+			fmt.Printf("Worker \"%s\" untainted\n", name)
+
+			return nil
+		},
+	}
+
+	return cmd
+}
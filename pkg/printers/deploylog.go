@@ -0,0 +1,54 @@
+package printers
+
+import (
+	"io"
+
+	"github.com/janog-netcon/netcon-problem-management-subsystem/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/cli-runtime/pkg/printers"
+)
+
+func generateTableBaseForDeployLog() *metav1.Table {
+	return &metav1.Table{
+		ColumnDefinitions: []metav1.TableColumnDefinition{
+			{Name: "Name", Type: "string", Format: "name"},
+			{Name: "Attempt", Type: "string"},
+			{Name: "Reset", Type: "string"},
+			{Name: "Result", Type: "string"},
+			{Name: "Age", Type: "string"},
+			{Name: "Error", Type: "string", Priority: 1},
+		},
+	}
+}
+
+// PrintDeployLogs prints the deploy logs recorded by nclet as ConfigMaps.
+func PrintDeployLogs(writer io.Writer, configMaps []corev1.ConfigMap, options PrintOptions) error {
+	table := generateTableBaseForDeployLog()
+
+	for _, configMap := range configMaps {
+		result, ok := configMap.Data["result"]
+		if !ok {
+			result = "<unknown>"
+		}
+		// the reset generation is missing in the deploy logs recorded by old nclet
+		resetGeneration, ok := configMap.Labels[v1alpha1.LabelDeployResetGeneration]
+		if !ok {
+			resetGeneration = "<unknown>"
+		}
+
+		cells := []interface{}{
+			configMap.Name,
+			configMap.Labels[v1alpha1.LabelDeployAttempt],
+			resetGeneration,
+			result,
+			translateTimestampSince(configMap.CreationTimestamp),
+		}
+		if options.Wide {
+			cells = append(cells, configMap.Data["error"])
+		}
+		table.Rows = append(table.Rows, metav1.TableRow{Cells: cells})
+	}
+
+	return printers.NewTablePrinter(printers.PrintOptions(options)).PrintObj(table, writer)
+}
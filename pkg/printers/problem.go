@@ -18,10 +18,14 @@ func generateTableBaseForProblemEnvironment() *metav1.Table {
 			{Name: "Name", Type: "string", Format: "name"},
 			{Name: "Ready", Type: "string"},
 			{Name: "Status", Type: "string"},
+			{Name: "Assignee", Type: "string"},
+			{Name: "Expires", Type: "string"},
 			{Name: "Age", Type: "string"},
 			{Name: "Worker", Type: "string", Priority: 1},
 			{Name: "Containers", Type: "string", Priority: 1},
+			{Name: "Restarts", Type: "integer", Priority: 1},
 			{Name: "Password", Type: "string", Priority: 1},
+			{Name: "Assignee Metadata", Type: "string", Priority: 1},
 		},
 	}
 }
@@ -43,6 +47,17 @@ func getStatusForProblemEnvironment(problemEnvironment *v1alpha1.ProblemEnvironm
 		return "Deleting"
 	}
 
+	if util.IsProblemEnvironmentResetting(problemEnvironment) {
+		return "Resetting"
+	}
+
+	if util.GetProblemEnvironmentCondition(
+		problemEnvironment,
+		v1alpha1.ProblemEnvironmentConditionFailed,
+	) == metav1.ConditionTrue {
+		return "Failed"
+	}
+
 	scheduled := util.GetProblemEnvironmentCondition(
 		problemEnvironment,
 		v1alpha1.ProblemEnvironmentConditionScheduled,
@@ -79,6 +94,13 @@ func getStatusForProblemEnvironment(problemEnvironment *v1alpha1.ProblemEnvironm
 		return "Unassigned"
 	}
 
+	if util.GetProblemEnvironmentCondition(
+		problemEnvironment,
+		v1alpha1.ProblemEnvironmentConditionHibernated,
+	) == metav1.ConditionTrue {
+		return "Assigned (Hibernated)"
+	}
+
 	return "Assigned"
 }
 
@@ -95,6 +117,15 @@ func getContainersForProblemEnvironment(problemEnvironment *v1alpha1.ProblemEnvi
 	return strings.Join(containerInfos, ",")
 }
 
+// getRestartsForProblemEnvironment returns the sum of the restarts of all containers.
+func getRestartsForProblemEnvironment(problemEnvironment *v1alpha1.ProblemEnvironment) int {
+	restarts := 0
+	for _, containerStatus := range problemEnvironment.Status.Containers {
+		restarts += containerStatus.RestartCount
+	}
+	return restarts
+}
+
 func translateTimestampSince(timestamp metav1.Time) string {
 	if timestamp.IsZero() {
 		return "<unknown>"
@@ -103,6 +134,48 @@ func translateTimestampSince(timestamp metav1.Time) string {
 	return duration.HumanDuration(time.Since(timestamp.Time))
 }
 
+// getExpiresForProblemEnvironment returns the remaining time of the assignment.
+func getExpiresForProblemEnvironment(problemEnvironment *v1alpha1.ProblemEnvironment) string {
+	expiresAt := problemEnvironment.Status.AssignmentExpiresAt
+	if expiresAt == nil {
+		return "<none>"
+	}
+
+	remaining := time.Until(expiresAt.Time)
+	if remaining <= 0 {
+		return "Expired"
+	}
+	return duration.HumanDuration(remaining)
+}
+
+func getAssigneeForProblemEnvironment(problemEnvironment *v1alpha1.ProblemEnvironment) string {
+	assignee := problemEnvironment.Status.Assignee
+	if assignee == nil {
+		return "<none>"
+	}
+	return assignee.ID
+}
+
+// getAssigneeMetadataForProblemEnvironment returns the metadata of the assignee as `key=value` sorted by key.
+func getAssigneeMetadataForProblemEnvironment(problemEnvironment *v1alpha1.ProblemEnvironment) string {
+	assignee := problemEnvironment.Status.Assignee
+	if assignee == nil || len(assignee.Metadata) == 0 {
+		return "<none>"
+	}
+
+	keys := make([]string, 0, len(assignee.Metadata))
+	for key := range assignee.Metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	items := make([]string, 0, len(keys))
+	for _, key := range keys {
+		items = append(items, key+"="+assignee.Metadata[key])
+	}
+	return strings.Join(items, ",")
+}
+
 func generateTableRowForProblemEnvironment(
 	problemEnvironment *v1alpha1.ProblemEnvironment,
 	options GenerateOptions,
@@ -110,14 +183,18 @@ func generateTableRowForProblemEnvironment(
 	name := problemEnvironment.Name
 	ready := getReadyForProblemEnvironment(problemEnvironment)
 	status := getStatusForProblemEnvironment(problemEnvironment)
+	assignee := getAssigneeForProblemEnvironment(problemEnvironment)
+	expires := getExpiresForProblemEnvironment(problemEnvironment)
 	age := translateTimestampSince(problemEnvironment.CreationTimestamp)
 	password := problemEnvironment.Status.Password
 	worker := problemEnvironment.Spec.WorkerName
 	containers := getContainersForProblemEnvironment(problemEnvironment)
 
-	cells := []interface{}{name, ready, status, age}
+	cells := []interface{}{name, ready, status, assignee, expires, age}
 	if options.Wide {
-		cells = append(cells, worker, containers, password)
+		assigneeMetadata := getAssigneeMetadataForProblemEnvironment(problemEnvironment)
+		restarts := getRestartsForProblemEnvironment(problemEnvironment)
+		cells = append(cells, worker, containers, restarts, password, assigneeMetadata)
 	}
 
 	return metav1.TableRow{Cells: cells}
@@ -0,0 +1,149 @@
+package printers
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/janog-netcon/netcon-problem-management-subsystem/api/v1alpha1"
+	"github.com/janog-netcon/netcon-problem-management-subsystem/pkg/scheduler"
+	"github.com/janog-netcon/netcon-problem-management-subsystem/pkg/util"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/cli-runtime/pkg/printers"
+)
+
+func generateTableBaseForWorkerPlacement() *metav1.Table {
+	return &metav1.Table{
+		ColumnDefinitions: []metav1.TableColumnDefinition{
+			{Name: "Worker", Type: "string", Format: "name"},
+			{Name: "Ready", Type: "string"},
+			{Name: "Scheduled", Type: "number"},
+			{Name: "New", Type: "number"},
+			{Name: "CPU", Type: "string"},
+			{Name: "Memory", Type: "string"},
+		},
+	}
+}
+
+// formatResourceUsage formats the sum of requests and allocatable as `requested/allocatable`.
+func formatResourceUsage(requested, allocatable corev1.ResourceList, name corev1.ResourceName) string {
+	request := requested[name]
+	capacity, ok := allocatable[name]
+	if !ok {
+		return fmt.Sprintf("%s/-", request.String())
+	}
+	return fmt.Sprintf("%s/%s", request.String(), capacity.String())
+}
+
+func generateTableForWorkerPlacement(
+	state *scheduler.CycleState,
+	placements []scheduler.Placement,
+) *metav1.Table {
+	table := generateTableBaseForWorkerPlacement()
+
+	placed := map[string]int{}
+	for _, placement := range placements {
+		if placement.WorkerName != "" {
+			placed[placement.WorkerName]++
+		}
+	}
+
+	for i := range state.Workers {
+		worker := &state.Workers[i]
+
+		scheduled := 0
+		requested := corev1.ResourceList{}
+		for _, pe := range state.ProblemEnvironments {
+			if pe.Spec.WorkerName != worker.Name {
+				continue
+			}
+			scheduled++
+			for name, quantity := range pe.Status.ResourceRequests {
+				total, ok := requested[name]
+				if !ok {
+					requested[name] = quantity.DeepCopy()
+					continue
+				}
+				total.Add(quantity)
+				requested[name] = total
+			}
+		}
+
+		ready := util.GetWorkerCondition(worker, v1alpha1.WorkerConditionReady) == metav1.ConditionTrue
+		allocatable := worker.Status.WorkerInfo.Allocatable
+
+		table.Rows = append(table.Rows, metav1.TableRow{Cells: []interface{}{
+			worker.Name,
+			ready,
+			scheduled,
+			placed[worker.Name],
+			formatResourceUsage(requested, allocatable, corev1.ResourceCPU),
+			formatResourceUsage(requested, allocatable, corev1.ResourceMemory),
+		}})
+	}
+
+	return table
+}
+
+func generateTableBaseForPlacement() *metav1.Table {
+	return &metav1.Table{
+		ColumnDefinitions: []metav1.TableColumnDefinition{
+			{Name: "Name", Type: "string", Format: "name"},
+			{Name: "Problem", Type: "string"},
+			{Name: "Worker", Type: "string"},
+			{Name: "Reason", Type: "string"},
+		},
+	}
+}
+
+func generateTableForPlacement(
+	placements []scheduler.Placement,
+	unschedulableOnly bool,
+) *metav1.Table {
+	table := generateTableBaseForPlacement()
+
+	for _, placement := range placements {
+		if unschedulableOnly && placement.WorkerName != "" {
+			continue
+		}
+
+		workerName, reason := placement.WorkerName, ""
+		if workerName == "" {
+			workerName = "<none>"
+			reason = placement.Diagnosis.String()
+		}
+
+		table.Rows = append(table.Rows, metav1.TableRow{Cells: []interface{}{
+			placement.ProblemEnvironment.Name,
+			placement.ProblemEnvironment.Labels[v1alpha1.LabelProblemName],
+			workerName,
+			reason,
+		}})
+	}
+
+	return table
+}
+
+// PrintSimulation prints the result of scheduler.Simulator. It prints the placement
+// per Worker and unschedulable ProblemEnvironments. If Wide is set, it prints the
+// placement of all ProblemEnvironments instead of unschedulable ones.
+func PrintSimulation(
+	writer io.Writer,
+	state *scheduler.CycleState,
+	placements []scheduler.Placement,
+	options PrintOptions,
+) error {
+	printer := printers.NewTablePrinter(printers.PrintOptions{})
+
+	if err := printer.PrintObj(generateTableForWorkerPlacement(state, placements), writer); err != nil {
+		return err
+	}
+
+	table := generateTableForPlacement(placements, !options.Wide)
+	if len(table.Rows) == 0 {
+		return nil
+	}
+
+	fmt.Fprintln(writer)
+	return printer.PrintObj(table, writer)
+}
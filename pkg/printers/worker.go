@@ -1,6 +1,8 @@
 package printers
 
 import (
+	"strings"
+
 	"github.com/janog-netcon/netcon-problem-management-subsystem/api/v1alpha1"
 	"github.com/janog-netcon/netcon-problem-management-subsystem/pkg/util"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -17,6 +19,7 @@ func generateTableBaseForWorker() *metav1.Table {
 			{Name: "Memory", Type: "string", Priority: 1},
 			{Name: "IPAddress", Type: "string", Priority: 1},
 			{Name: "Port", Type: "number", Priority: 1},
+			{Name: "Taints", Type: "string", Priority: 1},
 		},
 	}
 }
@@ -37,9 +40,14 @@ func generateTableRowForWorker(
 	ipAddress := worker.Status.WorkerInfo.ExternalIPAddress
 	port := worker.Status.WorkerInfo.ExternalPort
 
+	taints := []string{}
+	for _, taint := range worker.Spec.Taints {
+		taints = append(taints, taint.ToString())
+	}
+
 	cells := []interface{}{name, ready, enabled, age}
 	if options.Wide {
-		cells = append(cells, cpu, memory, ipAddress, port)
+		cells = append(cells, cpu, memory, ipAddress, port, strings.Join(taints, ","))
 	}
 
 	return metav1.TableRow{Cells: cells}
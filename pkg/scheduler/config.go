@@ -0,0 +1,132 @@
+package scheduler
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config describes which plugins are enabled and how they are weighted.
+//
+// Example:
+//
+//	filters:
+//	- name: WorkerReady
+//	- name: WorkerSchedulable
+//	- name: WorkerSelector
+//	- name: MemoryThreshold
+//	  args:
+//	    threshold: 90
+//	scores:
+//	- name: ResourceUsage
+//	  weight: 1
+//	  args:
+//	    cpuWeight: 1
+//	    memoryWeight: 3
+//	selection:
+//	  name: Boltzmann
+//	  args:
+//	    temperature: 0.1
+type Config struct {
+	Filters   []PluginConfig `yaml:"filters"`
+	Scores    []PluginConfig `yaml:"scores"`
+	Selection PluginConfig   `yaml:"selection"`
+}
+
+// PluginConfig refers a registered plugin with its arguments.
+type PluginConfig struct {
+	Name string `yaml:"name"`
+
+	// Weight is the weight of ScorePlugin. It's ignored for other plugins.
+	// If Weight is 0, 1 is used instead.
+	Weight float64 `yaml:"weight,omitempty"`
+
+	Args Args `yaml:"args,omitempty"`
+}
+
+// Args is the arguments for a plugin.
+type Args map[string]interface{}
+
+// Decode decodes Args into v, which should be a pointer to struct with yaml tags.
+func (a Args) Decode(v interface{}) error {
+	if len(a) == 0 {
+		return nil
+	}
+
+	data, err := yaml.Marshal(a)
+	if err != nil {
+		return err
+	}
+	return yaml.Unmarshal(data, v)
+}
+
+// LoadConfig loads Config from the YAML file.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scheduler config: %w", err)
+	}
+
+	config := Config{}
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal scheduler config: %w", err)
+	}
+
+	return &config, nil
+}
+
+// DefaultParameters is the parameters for the default Config.
+type DefaultParameters struct {
+	CPUWeight       float64
+	MemoryWeight    float64
+	MemoryThreshold float64
+	Temperature     float64
+
+	// MaxPendingDeployments is the maximum number of ProblemEnvironments
+	// being deployed on each Worker. 0 means unlimited.
+	MaxPendingDeployments int
+}
+
+// NewDefaultConfig returns Config which enables all in-tree plugins. Each plugin
+// does nothing without its data, like allocatable resources, taints, topology spread
+// constraints or images, so the placement is the same as before the scheduler became
+// pluggable until Workers, Problems and ProblemEnvironments start to use them.
+func NewDefaultConfig(params DefaultParameters) *Config {
+	return &Config{
+		Filters: []PluginConfig{
+			{Name: PluginWorkerReady},
+			{Name: PluginWorkerSchedulable},
+			{Name: PluginWorkerSelector},
+			{Name: PluginTaintToleration},
+			{
+				Name: PluginMemoryThreshold,
+				Args: Args{"threshold": params.MemoryThreshold},
+			},
+			{Name: PluginResourceFit},
+			{Name: PluginTopologySpread},
+			{
+				Name: PluginPendingDeployments,
+				Args: Args{"maxPending": params.MaxPendingDeployments},
+			},
+		},
+		Scores: []PluginConfig{
+			{Name: PluginTopologySpread, Weight: 1},
+			{Name: PluginTaintToleration, Weight: 1},
+			{Name: PluginPendingDeployments, Weight: 1},
+			{Name: PluginImageLocality, Weight: 1},
+			{
+				Name:   PluginResourceUsage,
+				Weight: 1,
+				Args: Args{
+					"cpuWeight":    params.CPUWeight,
+					"memoryWeight": params.MemoryWeight,
+				},
+			},
+		},
+		Selection: PluginConfig{
+			Name: SelectionBoltzmann,
+			Args: Args{"temperature": params.Temperature},
+		},
+	}
+}
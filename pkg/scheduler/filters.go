@@ -0,0 +1,265 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	netconv1alpha1 "github.com/janog-netcon/netcon-problem-management-subsystem/api/v1alpha1"
+	"github.com/janog-netcon/netcon-problem-management-subsystem/pkg/util"
+)
+
+const (
+	PluginWorkerReady       = "WorkerReady"
+	PluginWorkerSchedulable = "WorkerSchedulable"
+	PluginWorkerSelector    = "WorkerSelector"
+	PluginMemoryThreshold   = "MemoryThreshold"
+	PluginResourceFit       = "ResourceFit"
+)
+
+const MAX_USED_PERCENT float64 = 100.0
+
+// parseUsedPercent parses CPUUsedPercent or MemoryUsedPercent in WorkerInfo.
+// If it fails to parse, the Worker is considered to be fully used.
+func parseUsedPercent(ctx context.Context, value string, name string) float64 {
+	log := log.FromContext(ctx)
+
+	usedPercent, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		log.Error(err, "failed to parse "+name+" for worker election")
+		return MAX_USED_PERCENT
+	}
+	return usedPercent
+}
+
+// WorkerReady rejects Workers whose Ready condition is not True.
+type WorkerReady struct{}
+
+var _ FilterPlugin = &WorkerReady{}
+
+func newWorkerReady(_ Args) (Plugin, error) {
+	return &WorkerReady{}, nil
+}
+
+func (*WorkerReady) Name() string {
+	return PluginWorkerReady
+}
+
+// Filter implements FilterPlugin
+func (*WorkerReady) Filter(
+	ctx context.Context,
+	state *CycleState,
+	problemEnvironment *netconv1alpha1.ProblemEnvironment,
+	worker *netconv1alpha1.Worker,
+) error {
+	if util.GetWorkerCondition(worker, netconv1alpha1.WorkerConditionReady) != metav1.ConditionTrue {
+		return errors.New("worker is not ready")
+	}
+	return nil
+}
+
+// WorkerSchedulable rejects Workers whose DisableSchedule is true.
+type WorkerSchedulable struct{}
+
+var _ FilterPlugin = &WorkerSchedulable{}
+
+func newWorkerSchedulable(_ Args) (Plugin, error) {
+	return &WorkerSchedulable{}, nil
+}
+
+func (*WorkerSchedulable) Name() string {
+	return PluginWorkerSchedulable
+}
+
+// Filter implements FilterPlugin
+func (*WorkerSchedulable) Filter(
+	ctx context.Context,
+	state *CycleState,
+	problemEnvironment *netconv1alpha1.ProblemEnvironment,
+	worker *netconv1alpha1.Worker,
+) error {
+	if worker.Spec.DisableSchedule {
+		return errors.New("worker is disabled")
+	}
+	return nil
+}
+
+// WorkerSelector rejects Workers which match none of WorkerSelectors of the ProblemEnvironment.
+type WorkerSelector struct{}
+
+var _ FilterPlugin = &WorkerSelector{}
+
+func newWorkerSelector(_ Args) (Plugin, error) {
+	return &WorkerSelector{}, nil
+}
+
+func (*WorkerSelector) Name() string {
+	return PluginWorkerSelector
+}
+
+// Filter implements FilterPlugin
+func (*WorkerSelector) Filter(
+	ctx context.Context,
+	state *CycleState,
+	problemEnvironment *netconv1alpha1.ProblemEnvironment,
+	worker *netconv1alpha1.Worker,
+) error {
+	if !matchesWorkerSelectors(ctx, problemEnvironment, worker) {
+		return errors.New("worker doesn't match workerSelectors")
+	}
+	return nil
+}
+
+// matchesWorkerSelectors returns true if the Worker matches any of WorkerSelectors of the ProblemEnvironment.
+// If the ProblemEnvironment has no WorkerSelectors, all Workers match.
+func matchesWorkerSelectors(
+	ctx context.Context,
+	problemEnvironment *netconv1alpha1.ProblemEnvironment,
+	worker *netconv1alpha1.Worker,
+) bool {
+	log := log.FromContext(ctx)
+
+	workerSelectors := problemEnvironment.Spec.WorkerSelectors
+	if len(workerSelectors) == 0 {
+		return true
+	}
+
+	for _, selector := range workerSelectors {
+		s, err := metav1.LabelSelectorAsSelector(&selector)
+		if err != nil {
+			log.Error(err, "failed to parse label selector")
+			continue
+		}
+		if s.Matches(labels.Set(worker.Labels)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MemoryThreshold rejects Workers whose memory usage is above the threshold,
+// as it's too danger to deploy ProblemEnvironments to such Workers.
+type MemoryThreshold struct {
+	Threshold float64 `yaml:"threshold"`
+}
+
+var _ FilterPlugin = &MemoryThreshold{}
+
+func newMemoryThreshold(args Args) (Plugin, error) {
+	plugin := &MemoryThreshold{Threshold: 90.0}
+	if err := args.Decode(plugin); err != nil {
+		return nil, err
+	}
+	return plugin, nil
+}
+
+func (*MemoryThreshold) Name() string {
+	return PluginMemoryThreshold
+}
+
+// Filter implements FilterPlugin
+func (p *MemoryThreshold) Filter(
+	ctx context.Context,
+	state *CycleState,
+	problemEnvironment *netconv1alpha1.ProblemEnvironment,
+	worker *netconv1alpha1.Worker,
+) error {
+	memoryUsedPercent := parseUsedPercent(ctx, worker.Status.WorkerInfo.MemoryUsedPercent, "MemoryUsedPercent")
+	if memoryUsedPercent > p.Threshold {
+		return fmt.Errorf("memory usage %.1f%% is above threshold %.1f%%", memoryUsedPercent, p.Threshold)
+	}
+	return nil
+}
+
+// ResourceFit rejects Workers whose remaining allocatable resources can't fit
+// ResourceRequests of the ProblemEnvironment. The remaining allocatable resources
+// are Allocatable of the Worker minus ResourceRequests of ProblemEnvironments
+// already scheduled to the Worker.
+//
+// Workers which don't report Allocatable are never rejected.
+type ResourceFit struct{}
+
+var _ FilterPlugin = &ResourceFit{}
+
+func newResourceFit(_ Args) (Plugin, error) {
+	return &ResourceFit{}, nil
+}
+
+func (*ResourceFit) Name() string {
+	return PluginResourceFit
+}
+
+// Filter implements FilterPlugin
+func (*ResourceFit) Filter(
+	ctx context.Context,
+	state *CycleState,
+	problemEnvironment *netconv1alpha1.ProblemEnvironment,
+	worker *netconv1alpha1.Worker,
+) error {
+	allocatable := worker.Status.WorkerInfo.Allocatable
+	if len(allocatable) == 0 {
+		return nil
+	}
+
+	requested := requestedResources(state, problemEnvironment, worker)
+
+	for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		request, ok := problemEnvironment.Status.ResourceRequests[name]
+		if !ok || request.IsZero() {
+			continue
+		}
+
+		capacity, ok := allocatable[name]
+		if !ok {
+			continue
+		}
+
+		remaining := capacity.DeepCopy()
+		used := requested[name]
+		remaining.Sub(used)
+
+		if request.Cmp(remaining) > 0 {
+			return fmt.Errorf(
+				"insufficient %s: requested %s, remaining %s",
+				name, request.String(), remaining.String(),
+			)
+		}
+	}
+
+	return nil
+}
+
+// requestedResources returns the sum of ResourceRequests of ProblemEnvironments
+// scheduled to the Worker, except the ProblemEnvironment being scheduled.
+func requestedResources(
+	state *CycleState,
+	problemEnvironment *netconv1alpha1.ProblemEnvironment,
+	worker *netconv1alpha1.Worker,
+) corev1.ResourceList {
+	requested := corev1.ResourceList{}
+
+	for i := range state.ProblemEnvironments {
+		pe := &state.ProblemEnvironments[i]
+		if pe.Spec.WorkerName != worker.Name {
+			continue
+		}
+		if pe.Namespace == problemEnvironment.Namespace && pe.Name == problemEnvironment.Name {
+			continue
+		}
+
+		for name, quantity := range pe.Status.ResourceRequests {
+			total := requested[name]
+			total.Add(quantity)
+			requested[name] = total
+		}
+	}
+
+	return requested
+}
@@ -0,0 +1,208 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	netconv1alpha1 "github.com/janog-netcon/netcon-problem-management-subsystem/api/v1alpha1"
+)
+
+type weightedScorePlugin struct {
+	ScorePlugin
+	weight float64
+}
+
+// Framework runs the plugins enabled by Config to elect a Worker for a ProblemEnvironment.
+type Framework struct {
+	filters   []FilterPlugin
+	scores    []weightedScorePlugin
+	selection SelectionStrategy
+
+	random RandomSource
+}
+
+// Option configures Framework.
+type Option func(*Framework)
+
+// WithRandomSource makes SelectionStrategy draw random numbers from random instead of
+// the global source. It's useful to make the election reproducible with a fixed seed.
+func WithRandomSource(random RandomSource) Option {
+	return func(f *Framework) {
+		f.random = random
+	}
+}
+
+// Diagnosis holds the reason why each Worker was rejected, keyed by the name of Worker.
+type Diagnosis map[string]error
+
+func (d Diagnosis) String() string {
+	names := make([]string, 0, len(d))
+	for name := range d {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	reasons := make([]string, 0, len(d))
+	for _, name := range names {
+		reasons = append(reasons, fmt.Sprintf("%s: %s", name, d[name]))
+	}
+	return strings.Join(reasons, ", ")
+}
+
+func NewFramework(config *Config, registry Registry, opts ...Option) (*Framework, error) {
+	build := func(pluginConfig PluginConfig) (Plugin, error) {
+		factory, ok := registry[pluginConfig.Name]
+		if !ok {
+			return nil, fmt.Errorf("plugin not found: %s", pluginConfig.Name)
+		}
+
+		plugin, err := factory(pluginConfig.Args)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build plugin %s: %w", pluginConfig.Name, err)
+		}
+		return plugin, nil
+	}
+
+	f := &Framework{random: globalRandomSource{}}
+	for _, opt := range opts {
+		opt(f)
+	}
+
+	for _, pluginConfig := range config.Filters {
+		plugin, err := build(pluginConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		filter, ok := plugin.(FilterPlugin)
+		if !ok {
+			return nil, fmt.Errorf("plugin %s is not FilterPlugin", pluginConfig.Name)
+		}
+		f.filters = append(f.filters, filter)
+	}
+
+	for _, pluginConfig := range config.Scores {
+		plugin, err := build(pluginConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		score, ok := plugin.(ScorePlugin)
+		if !ok {
+			return nil, fmt.Errorf("plugin %s is not ScorePlugin", pluginConfig.Name)
+		}
+
+		weight := pluginConfig.Weight
+		if weight == 0 {
+			weight = 1
+		}
+		if weight < 0 {
+			return nil, fmt.Errorf("weight of plugin %s must be positive", pluginConfig.Name)
+		}
+		f.scores = append(f.scores, weightedScorePlugin{ScorePlugin: score, weight: weight})
+	}
+
+	selectionConfig := config.Selection
+	if selectionConfig.Name == "" {
+		selectionConfig.Name = SelectionBoltzmann
+	}
+	plugin, err := build(selectionConfig)
+	if err != nil {
+		return nil, err
+	}
+	selection, ok := plugin.(SelectionStrategy)
+	if !ok {
+		return nil, fmt.Errorf("plugin %s is not SelectionStrategy", selectionConfig.Name)
+	}
+	f.selection = selection
+
+	return f, nil
+}
+
+// FindCandidates runs FilterPlugins and ScorePlugins against all Workers in state.
+// It returns the Workers which passed all FilterPlugins, and the reasons why other Workers were rejected.
+func (f *Framework) FindCandidates(
+	ctx context.Context,
+	state *CycleState,
+	problemEnvironment *netconv1alpha1.ProblemEnvironment,
+) ([]Candidate, Diagnosis) {
+	candidates := []Candidate{}
+	diagnosis := Diagnosis{}
+
+	for i := range state.Workers {
+		worker := &state.Workers[i]
+
+		if err := f.runFilters(ctx, state, problemEnvironment, worker); err != nil {
+			diagnosis[worker.Name] = err
+			continue
+		}
+
+		candidates = append(candidates, Candidate{
+			Name:  worker.Name,
+			Score: f.runScores(ctx, state, problemEnvironment, worker),
+		})
+	}
+
+	return candidates, diagnosis
+}
+
+// Select elects a Worker from candidates with SelectionStrategy.
+// It returns empty string if candidates is empty.
+func (f *Framework) Select(candidates []Candidate) string {
+	if len(candidates) == 0 {
+		return ""
+	}
+	return f.selection.Select(candidates, f.random)
+}
+
+// Schedule elects a Worker for the ProblemEnvironment.
+// It returns empty string if there is no Worker which can host the ProblemEnvironment.
+func (f *Framework) Schedule(
+	ctx context.Context,
+	state *CycleState,
+	problemEnvironment *netconv1alpha1.ProblemEnvironment,
+) (string, Diagnosis) {
+	candidates, diagnosis := f.FindCandidates(ctx, state, problemEnvironment)
+	return f.Select(candidates), diagnosis
+}
+
+func (f *Framework) runFilters(
+	ctx context.Context,
+	state *CycleState,
+	problemEnvironment *netconv1alpha1.ProblemEnvironment,
+	worker *netconv1alpha1.Worker,
+) error {
+	for _, filter := range f.filters {
+		if err := filter.Filter(ctx, state, problemEnvironment, worker); err != nil {
+			return fmt.Errorf("%s: %w", filter.Name(), err)
+		}
+	}
+	return nil
+}
+
+// runScores returns the weighted average of the scores, which is scaled from 0 to 1.
+func (f *Framework) runScores(
+	ctx context.Context,
+	state *CycleState,
+	problemEnvironment *netconv1alpha1.ProblemEnvironment,
+	worker *netconv1alpha1.Worker,
+) float64 {
+	if len(f.scores) == 0 {
+		return 0
+	}
+
+	total, totalWeight := 0.0, 0.0
+	for _, score := range f.scores {
+		if skipper, ok := score.ScorePlugin.(ScoreSkipper); ok && skipper.SkipScore(state, problemEnvironment) {
+			continue
+		}
+		total += score.Score(ctx, state, problemEnvironment, worker) * score.weight
+		totalWeight += score.weight
+	}
+	if totalWeight == 0 {
+		return 0
+	}
+	return total / totalWeight
+}
@@ -0,0 +1,713 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	netconv1alpha1 "github.com/janog-netcon/netcon-problem-management-subsystem/api/v1alpha1"
+	"github.com/janog-netcon/netcon-problem-management-subsystem/pkg/containerlab"
+	"github.com/janog-netcon/netcon-problem-management-subsystem/pkg/util"
+)
+
+func newTestWorker(name string, ready bool, cpuUsedPercent, memoryUsedPercent string) netconv1alpha1.Worker {
+	worker := netconv1alpha1.Worker{}
+	worker.Name = name
+	worker.Status.WorkerInfo.CPUUsedPercent = cpuUsedPercent
+	worker.Status.WorkerInfo.MemoryUsedPercent = memoryUsedPercent
+
+	status := metav1.ConditionFalse
+	if ready {
+		status = metav1.ConditionTrue
+	}
+	util.SetWorkerCondition(&worker, netconv1alpha1.WorkerConditionReady, status, "Test", "test")
+
+	return worker
+}
+
+// testParameters is the parameters of the default Config used in tests.
+var testParameters = DefaultParameters{
+	CPUWeight:       1.0,
+	MemoryWeight:    3.0,
+	MemoryThreshold: 90.0,
+	Temperature:     0.1,
+}
+
+func newTestFramework(t *testing.T, selection string) *Framework {
+	config := NewDefaultConfig(testParameters)
+	config.Selection = PluginConfig{Name: selection}
+
+	framework, err := NewFramework(config, NewInTreeRegistry())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return framework
+}
+
+// newDefaultFramework returns Framework with the default Config as controller-manager runs it,
+// electing Workers with the seeded Boltzmann selection.
+func newDefaultFramework(t *testing.T) *Framework {
+	framework, err := NewFramework(
+		NewDefaultConfig(testParameters),
+		NewInTreeRegistry(),
+		WithRandomSource(NewSeededRandomSource(42)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return framework
+}
+
+// scheduleMany schedules the ProblemEnvironment n times, and returns how many times each Worker was elected.
+func scheduleMany(
+	framework *Framework,
+	state *CycleState,
+	problemEnvironment *netconv1alpha1.ProblemEnvironment,
+	n int,
+) map[string]int {
+	elected := map[string]int{}
+	for range n {
+		workerName, _ := framework.Schedule(context.Background(), state, problemEnvironment)
+		elected[workerName]++
+	}
+	return elected
+}
+
+func TestFrameworkFilters(t *testing.T) {
+	framework := newTestFramework(t, SelectionMaxScore)
+
+	notReady := newTestWorker("not-ready", false, "10.0", "10.0")
+	disabled := newTestWorker("disabled", true, "10.0", "10.0")
+	disabled.Spec.DisableSchedule = true
+	unmatched := newTestWorker("unmatched", true, "10.0", "10.0")
+	unmatched.Labels = map[string]string{"class": "foo"}
+	memoryFull := newTestWorker("memory-full", true, "10.0", "95.0")
+	invalidMetrics := newTestWorker("invalid-metrics", true, "", "")
+	schedulable := newTestWorker("schedulable", true, "50.0", "50.0")
+	schedulable.Labels = map[string]string{"class": "bar"}
+
+	state := CycleState{
+		Workers: []netconv1alpha1.Worker{
+			notReady, disabled, unmatched, memoryFull, invalidMetrics, schedulable,
+		},
+	}
+
+	problemEnvironment := netconv1alpha1.ProblemEnvironment{}
+	problemEnvironment.Spec.WorkerSelectors = []metav1.LabelSelector{
+		{MatchExpressions: []metav1.LabelSelectorRequirement{
+			{Key: "class", Operator: metav1.LabelSelectorOpNotIn, Values: []string{"foo"}},
+		}},
+	}
+
+	candidates, diagnosis := framework.FindCandidates(context.Background(), &state, &problemEnvironment)
+	if len(candidates) != 1 || candidates[0].Name != "schedulable" {
+		t.Fatalf("unexpected candidates: %v", candidates)
+	}
+	for _, name := range []string{"not-ready", "disabled", "unmatched", "memory-full", "invalid-metrics"} {
+		if diagnosis[name] == nil {
+			t.Errorf("%s should be rejected", name)
+		}
+	}
+}
+
+func TestFrameworkScheduleWithMaxScore(t *testing.T) {
+	framework := newTestFramework(t, SelectionMaxScore)
+
+	state := CycleState{
+		Workers: []netconv1alpha1.Worker{
+			newTestWorker("worker-001", true, "50.0", "50.0"),
+			newTestWorker("worker-002", true, "10.0", "20.0"),
+			newTestWorker("worker-003", true, "5.0", "60.0"),
+		},
+	}
+
+	workerName, _ := framework.Schedule(context.Background(), &state, &netconv1alpha1.ProblemEnvironment{})
+	if workerName != "worker-002" {
+		t.Fatalf("expected worker-002, got %s", workerName)
+	}
+}
+
+func TestFrameworkScheduleWithoutCandidates(t *testing.T) {
+	framework := newTestFramework(t, SelectionBoltzmann)
+
+	state := CycleState{
+		Workers: []netconv1alpha1.Worker{
+			newTestWorker("worker-001", false, "10.0", "10.0"),
+		},
+	}
+
+	workerName, diagnosis := framework.Schedule(context.Background(), &state, &netconv1alpha1.ProblemEnvironment{})
+	if workerName != "" {
+		t.Fatalf("expected no worker, got %s", workerName)
+	}
+	if len(diagnosis) != 1 {
+		t.Fatalf("unexpected diagnosis: %s", diagnosis)
+	}
+}
+
+func TestFrameworkResourceFit(t *testing.T) {
+	framework := newTestFramework(t, SelectionMaxScore)
+
+	newResourceList := func(cpu, memory string) corev1.ResourceList {
+		return corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse(cpu),
+			corev1.ResourceMemory: resource.MustParse(memory),
+		}
+	}
+
+	full := newTestWorker("full", true, "10.0", "10.0")
+	full.Status.WorkerInfo.Allocatable = newResourceList("8", "16Gi")
+	available := newTestWorker("available", true, "50.0", "50.0")
+	available.Status.WorkerInfo.Allocatable = newResourceList("8", "16Gi")
+	unreported := newTestWorker("unreported", true, "90.0", "80.0")
+
+	scheduled := netconv1alpha1.ProblemEnvironment{}
+	scheduled.Name = "scheduled"
+	scheduled.Spec.WorkerName = "full"
+	scheduled.Status.ResourceRequests = newResourceList("6", "8Gi")
+
+	problemEnvironment := netconv1alpha1.ProblemEnvironment{}
+	problemEnvironment.Name = "pending"
+	problemEnvironment.Status.ResourceRequests = newResourceList("4", "4Gi")
+
+	state := CycleState{
+		Workers:             []netconv1alpha1.Worker{full, available, unreported},
+		ProblemEnvironments: []netconv1alpha1.ProblemEnvironment{scheduled, problemEnvironment},
+	}
+
+	candidates, diagnosis := framework.FindCandidates(context.Background(), &state, &problemEnvironment)
+	if len(candidates) != 2 {
+		t.Fatalf("unexpected candidates: %v", candidates)
+	}
+	if diagnosis["full"] == nil {
+		t.Errorf("full should be rejected")
+	}
+}
+
+func TestFrameworkTopologySpread(t *testing.T) {
+	framework := newTestFramework(t, SelectionMaxScore)
+
+	newWorker := func(name string) netconv1alpha1.Worker {
+		worker := newTestWorker(name, true, "10.0", "10.0")
+		worker.Labels = map[string]string{netconv1alpha1.LabelWorkerName: name}
+		return worker
+	}
+	newProblemEnvironment := func(name, workerName string) netconv1alpha1.ProblemEnvironment {
+		pe := netconv1alpha1.ProblemEnvironment{}
+		pe.Name = name
+		pe.Labels = map[string]string{netconv1alpha1.LabelProblemName: "problem"}
+		pe.Spec.WorkerName = workerName
+		return pe
+	}
+
+	problem := netconv1alpha1.Problem{}
+	problem.Name = "problem"
+	problem.Spec.TopologySpreadConstraints = []netconv1alpha1.TopologySpreadConstraint{
+		{MaxSkew: 1, TopologyKey: netconv1alpha1.LabelWorkerName},
+	}
+
+	unlabeled := newTestWorker("unlabeled", true, "10.0", "10.0")
+	problemEnvironment := newProblemEnvironment("problem-003", "")
+
+	state := CycleState{
+		Workers: []netconv1alpha1.Worker{newWorker("worker-001"), newWorker("worker-002"), unlabeled},
+		ProblemEnvironments: []netconv1alpha1.ProblemEnvironment{
+			newProblemEnvironment("problem-001", "worker-001"),
+			newProblemEnvironment("problem-002", "worker-001"),
+			problemEnvironment,
+		},
+		Problems: []netconv1alpha1.Problem{problem},
+	}
+
+	candidates, diagnosis := framework.FindCandidates(context.Background(), &state, &problemEnvironment)
+	if len(candidates) != 1 || candidates[0].Name != "worker-002" {
+		t.Fatalf("unexpected candidates: %v", candidates)
+	}
+	if diagnosis["worker-001"] == nil || diagnosis["unlabeled"] == nil {
+		t.Errorf("unexpected diagnosis: %s", diagnosis)
+	}
+
+	problem.Spec.TopologySpreadConstraints[0].WhenUnsatisfiable = netconv1alpha1.ScheduleAnyway
+	state.Problems = []netconv1alpha1.Problem{problem}
+
+	workerName, _ := framework.Schedule(context.Background(), &state, &problemEnvironment)
+	if workerName != "worker-002" {
+		t.Fatalf("expected worker-002, got %s", workerName)
+	}
+}
+
+func TestDefaultConfigTopologySpread(t *testing.T) {
+	framework := newDefaultFramework(t)
+
+	newWorker := func(name, zone, usedPercent string) netconv1alpha1.Worker {
+		worker := newTestWorker(name, true, usedPercent, usedPercent)
+		worker.Labels = map[string]string{"zone": zone}
+		return worker
+	}
+	newProblemEnvironment := func(name, workerName string) netconv1alpha1.ProblemEnvironment {
+		pe := netconv1alpha1.ProblemEnvironment{}
+		pe.Name = name
+		pe.Labels = map[string]string{netconv1alpha1.LabelProblemName: "problem"}
+		pe.Spec.WorkerName = workerName
+		return pe
+	}
+
+	problem := netconv1alpha1.Problem{}
+	problem.Name = "problem"
+	problem.Spec.TopologySpreadConstraints = []netconv1alpha1.TopologySpreadConstraint{
+		{MaxSkew: 1, TopologyKey: "zone"},
+	}
+
+	problemEnvironment := newProblemEnvironment("problem-003", "")
+
+	// zone-a is less loaded, but it already has 2 ProblemEnvironments of the Problem
+	state := CycleState{
+		Workers: []netconv1alpha1.Worker{
+			newWorker("worker-001", "zone-a", "5.0"),
+			newWorker("worker-002", "zone-a", "5.0"),
+			newWorker("worker-003", "zone-b", "50.0"),
+		},
+		ProblemEnvironments: []netconv1alpha1.ProblemEnvironment{
+			newProblemEnvironment("problem-001", "worker-001"),
+			newProblemEnvironment("problem-002", "worker-002"),
+			problemEnvironment,
+		},
+		Problems: []netconv1alpha1.Problem{problem},
+	}
+
+	elected := scheduleMany(framework, &state, &problemEnvironment, 100)
+	if elected["worker-003"] != 100 {
+		t.Fatalf("ProblemEnvironment should be spread to zone-b: %v", elected)
+	}
+}
+
+func TestFrameworkPendingDeployments(t *testing.T) {
+	newFramework := func(maxPending int) *Framework {
+		params := testParameters
+		params.MaxPendingDeployments = maxPending
+
+		config := NewDefaultConfig(params)
+		config.Selection = PluginConfig{Name: SelectionMaxScore}
+
+		framework, err := NewFramework(config, NewInTreeRegistry())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return framework
+	}
+
+	newPendingProblemEnvironment := func(name, workerName string) netconv1alpha1.ProblemEnvironment {
+		pe := netconv1alpha1.ProblemEnvironment{}
+		pe.Name = name
+		pe.Spec.WorkerName = workerName
+		return pe
+	}
+
+	deployed := newPendingProblemEnvironment("deployed", "worker-002")
+	util.SetProblemEnvironmentCondition(
+		&deployed,
+		netconv1alpha1.ProblemEnvironmentConditionDeployed,
+		metav1.ConditionTrue,
+		"Test", "test",
+	)
+
+	// nclet gave up deploying it, so it's not pending anymore
+	failed := newPendingProblemEnvironment("failed", "worker-002")
+	util.SetProblemEnvironmentCondition(
+		&failed,
+		netconv1alpha1.ProblemEnvironmentConditionFailed,
+		metav1.ConditionTrue,
+		"Test", "test",
+	)
+
+	// worker-001 looks idle, but it has 2 pending deployments,
+	// while worker-002 has none as the deployed and failed ones are not pending
+	state := CycleState{
+		Workers: []netconv1alpha1.Worker{
+			newTestWorker("worker-001", true, "5.0", "5.0"),
+			newTestWorker("worker-002", true, "10.0", "10.0"),
+		},
+		ProblemEnvironments: []netconv1alpha1.ProblemEnvironment{
+			newPendingProblemEnvironment("pending-001", "worker-001"),
+			newPendingProblemEnvironment("pending-002", "worker-001"),
+			deployed,
+			failed,
+		},
+	}
+
+	t.Run("penalized below the cap", func(t *testing.T) {
+		candidates, diagnosis := newFramework(3).FindCandidates(
+			context.Background(), &state, &netconv1alpha1.ProblemEnvironment{},
+		)
+		if len(candidates) != 2 {
+			t.Fatalf("worker-001 should not be rejected below the cap: %s", diagnosis)
+		}
+		if candidates[0].Score >= candidates[1].Score {
+			t.Fatalf("worker-001 should be scored lower than worker-002: %v", candidates)
+		}
+	})
+
+	t.Run("filtered at the cap", func(t *testing.T) {
+		candidates, diagnosis := newFramework(2).FindCandidates(
+			context.Background(), &state, &netconv1alpha1.ProblemEnvironment{},
+		)
+		if len(candidates) != 1 || candidates[0].Name != "worker-002" {
+			t.Fatalf("unexpected candidates: %v", candidates)
+		}
+		if diagnosis["worker-001"] == nil {
+			t.Errorf("worker-001 should be rejected at the cap")
+		}
+	})
+
+	t.Run("unlimited", func(t *testing.T) {
+		candidates, _ := newFramework(0).FindCandidates(
+			context.Background(), &state, &netconv1alpha1.ProblemEnvironment{},
+		)
+		if len(candidates) != 2 {
+			t.Fatalf("no Worker should be rejected without the cap: %v", candidates)
+		}
+	})
+}
+
+func TestFrameworkTaintToleration(t *testing.T) {
+	framework := newTestFramework(t, SelectionMaxScore)
+
+	dedicated := newTestWorker("dedicated", true, "10.0", "10.0")
+	dedicated.Spec.Taints = []corev1.Taint{
+		{Key: "dedicated", Value: "heavy-nos", Effect: corev1.TaintEffectNoSchedule},
+	}
+	maintenance := newTestWorker("maintenance", true, "10.0", "10.0")
+	maintenance.Spec.Taints = []corev1.Taint{
+		{Key: "maintenance", Effect: corev1.TaintEffectNoSchedule},
+	}
+	preferred := newTestWorker("preferred", true, "5.0", "5.0")
+	preferred.Spec.Taints = []corev1.Taint{
+		{Key: "dedicated", Value: "heavy-nos", Effect: corev1.TaintEffectPreferNoSchedule},
+	}
+	untainted := newTestWorker("untainted", true, "10.0", "10.0")
+
+	state := CycleState{
+		Workers: []netconv1alpha1.Worker{dedicated, maintenance, preferred, untainted},
+	}
+
+	// ProblemEnvironment without tolerations avoids all tainted Workers
+	workerName, diagnosis := framework.Schedule(context.Background(), &state, &netconv1alpha1.ProblemEnvironment{})
+	if workerName != "untainted" {
+		t.Fatalf("expected untainted, got %s", workerName)
+	}
+	if diagnosis["dedicated"] == nil || diagnosis["maintenance"] == nil {
+		t.Errorf("unexpected diagnosis: %s", diagnosis)
+	}
+
+	problemEnvironment := netconv1alpha1.ProblemEnvironment{}
+	problemEnvironment.Spec.Tolerations = []corev1.Toleration{
+		{Key: "dedicated", Operator: corev1.TolerationOpEqual, Value: "heavy-nos"},
+	}
+
+	candidates, _ := framework.FindCandidates(context.Background(), &state, &problemEnvironment)
+	if len(candidates) != 3 {
+		t.Fatalf("unexpected candidates: %v", candidates)
+	}
+}
+
+func TestDefaultConfigAvoidsNoScheduleTaint(t *testing.T) {
+	framework := newDefaultFramework(t)
+
+	// the tainted Worker is the least loaded, so it would be preferred without the taint
+	tainted := newTestWorker("tainted", true, "5.0", "5.0")
+	tainted.Spec.Taints = []corev1.Taint{
+		{Key: "maintenance", Effect: corev1.TaintEffectNoSchedule},
+	}
+
+	state := CycleState{
+		Workers: []netconv1alpha1.Worker{
+			tainted,
+			newTestWorker("worker-001", true, "40.0", "40.0"),
+			newTestWorker("worker-002", true, "50.0", "50.0"),
+		},
+	}
+
+	elected := scheduleMany(framework, &state, &netconv1alpha1.ProblemEnvironment{}, 100)
+	if elected["tainted"] != 0 {
+		t.Fatalf("the Worker with the NoSchedule taint should never be elected: %v", elected)
+	}
+	if elected["worker-001"]+elected["worker-002"] != 100 {
+		t.Fatalf("unexpected election: %v", elected)
+	}
+}
+
+func TestFrameworkImageLocality(t *testing.T) {
+	framework := newTestFramework(t, SelectionMaxScore)
+
+	cold := newTestWorker("cold", true, "5.0", "5.0")
+	partial := newTestWorker("partial", true, "10.0", "10.0")
+	partial.Status.Images = []corev1.ContainerImage{
+		{Names: []string{"alpine:latest"}},
+	}
+	warm := newTestWorker("warm", true, "10.0", "10.0")
+	warm.Status.Images = []corev1.ContainerImage{
+		{Names: []string{"alpine:latest"}},
+		{Names: []string{"ceos:4.28.0F", "ceos@sha256:0123"}},
+	}
+
+	state := CycleState{
+		Workers: []netconv1alpha1.Worker{cold, partial, warm},
+		Topology: &containerlab.Topology{
+			Kinds: map[string]*containerlab.NodeDefinition{
+				"ceos": {Image: "ceos:4.28.0F"},
+			},
+			Nodes: map[string]*containerlab.NodeDefinition{
+				"r1":   {Kind: "ceos"},
+				"r2":   {Kind: "ceos"},
+				"host": {Kind: "linux", Image: "docker.io/library/alpine"},
+			},
+		},
+	}
+
+	workerName, _ := framework.Schedule(context.Background(), &state, &netconv1alpha1.ProblemEnvironment{})
+	if workerName != "warm" {
+		t.Fatalf("expected warm, got %s", workerName)
+	}
+}
+
+func TestDefaultConfigImageLocality(t *testing.T) {
+	framework := newDefaultFramework(t)
+
+	cold := newTestWorker("cold", true, "20.0", "20.0")
+	warm := newTestWorker("warm", true, "20.0", "20.0")
+	warm.Status.Images = []corev1.ContainerImage{
+		{Names: []string{"ceos:4.28.0F"}},
+	}
+
+	state := CycleState{
+		Workers: []netconv1alpha1.Worker{cold, warm},
+		Topology: &containerlab.Topology{
+			Nodes: map[string]*containerlab.NodeDefinition{
+				"r1": {Kind: "ceos", Image: "ceos:4.28.0F"},
+			},
+		},
+	}
+
+	// both Workers are equally loaded, so the Worker holding the image should be preferred
+	elected := scheduleMany(framework, &state, &netconv1alpha1.ProblemEnvironment{}, 100)
+	if elected["warm"] <= elected["cold"] {
+		t.Fatalf("the Worker holding the image should be preferred: %v", elected)
+	}
+
+	state.Topology = nil
+	elected = scheduleMany(framework, &state, &netconv1alpha1.ProblemEnvironment{}, 100)
+	if elected["warm"] == 0 || elected["cold"] == 0 {
+		t.Fatalf("Workers should be elected evenly without the topology: %v", elected)
+	}
+}
+
+func TestBoltzmannWithSeededRandomSource(t *testing.T) {
+	candidates := []Candidate{
+		{Name: "worker-001", Score: 0.5},
+		{Name: "worker-002", Score: 0.5},
+		{Name: "worker-003", Score: 0.5},
+	}
+
+	elect := func(seed uint64) []string {
+		selection := &Boltzmann{Temperature: 0.1}
+		random := NewSeededRandomSource(seed)
+
+		elected := []string{}
+		for range 20 {
+			elected = append(elected, selection.Select(candidates, random))
+		}
+		return elected
+	}
+
+	if !reflect.DeepEqual(elect(1), elect(1)) {
+		t.Fatal("election is not reproducible with the same seed")
+	}
+	if reflect.DeepEqual(elect(1), elect(2)) {
+		t.Fatal("election doesn't depend on the seed")
+	}
+}
+
+func TestSimulator(t *testing.T) {
+	simulate := func(seed uint64) []Placement {
+		framework, err := NewFramework(
+			NewDefaultConfig(testParameters),
+			NewInTreeRegistry(),
+			WithRandomSource(NewSeededRandomSource(seed)),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		workers := []netconv1alpha1.Worker{}
+		for _, name := range []string{"worker-001", "worker-002"} {
+			worker := newTestWorker(name, true, "10.0", "10.0")
+			worker.Status.WorkerInfo.Allocatable = corev1.ResourceList{
+				corev1.ResourceCPU: resource.MustParse("4"),
+			}
+			workers = append(workers, worker)
+		}
+
+		problemEnvironments := []netconv1alpha1.ProblemEnvironment{}
+		for i := range 5 {
+			pe := netconv1alpha1.ProblemEnvironment{}
+			pe.Name = fmt.Sprintf("pe-%d", i)
+			pe.Status.ResourceRequests = corev1.ResourceList{
+				corev1.ResourceCPU: resource.MustParse("2"),
+			}
+			problemEnvironments = append(problemEnvironments, pe)
+		}
+
+		simulator := NewSimulator(framework, CycleState{Workers: workers})
+		return simulator.Simulate(
+			context.Background(),
+			problemEnvironments,
+			func(*netconv1alpha1.ProblemEnvironment) *containerlab.Topology { return nil },
+		)
+	}
+
+	placements := simulate(42)
+
+	placed := map[string]int{}
+	for _, placement := range placements[:4] {
+		placed[placement.WorkerName]++
+	}
+	if placed["worker-001"] != 2 || placed["worker-002"] != 2 {
+		t.Fatalf("unexpected placements: %v", placed)
+	}
+	if placements[4].WorkerName != "" || len(placements[4].Diagnosis) != 2 {
+		t.Fatalf("pe-4 should be unschedulable: %s", placements[4].Diagnosis)
+	}
+
+	for i, placement := range simulate(42) {
+		if placement.WorkerName != placements[i].WorkerName {
+			t.Fatalf("placement is not reproducible with the same seed")
+		}
+	}
+}
+
+func TestNewDefaultConfig(t *testing.T) {
+	// baseline is Config used before the scheduler became pluggable
+	baseline := &Config{
+		Filters: []PluginConfig{
+			{Name: PluginWorkerReady},
+			{Name: PluginWorkerSchedulable},
+			{Name: PluginWorkerSelector},
+			{Name: PluginMemoryThreshold, Args: Args{"threshold": 90.0}},
+		},
+		Scores: []PluginConfig{
+			{Name: PluginResourceUsage, Args: Args{"cpuWeight": 1.0, "memoryWeight": 3.0}},
+		},
+		Selection: PluginConfig{Name: SelectionBoltzmann, Args: Args{"temperature": 0.1}},
+	}
+
+	// Workers, ProblemEnvironments and the topology have no data for the other plugins
+	state := CycleState{
+		Workers: []netconv1alpha1.Worker{
+			newTestWorker("not-ready", false, "10.0", "10.0"),
+			newTestWorker("memory-full", true, "10.0", "95.0"),
+			newTestWorker("worker-001", true, "50.0", "50.0"),
+			newTestWorker("worker-002", true, "10.0", "20.0"),
+			newTestWorker("worker-003", true, "5.0", "60.0"),
+		},
+		Topology: &containerlab.Topology{
+			Nodes: map[string]*containerlab.NodeDefinition{
+				"host": {Kind: "linux"},
+			},
+		},
+	}
+
+	schedule := func(config *Config) ([]Candidate, []string) {
+		framework, err := NewFramework(config, NewInTreeRegistry(), WithRandomSource(NewSeededRandomSource(42)))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		candidates, _ := framework.FindCandidates(context.Background(), &state, &netconv1alpha1.ProblemEnvironment{})
+		elected := []string{}
+		for range 20 {
+			workerName, _ := framework.Schedule(context.Background(), &state, &netconv1alpha1.ProblemEnvironment{})
+			elected = append(elected, workerName)
+		}
+		return candidates, elected
+	}
+
+	candidates, elected := schedule(NewDefaultConfig(testParameters))
+	baselineCandidates, baselineElected := schedule(baseline)
+
+	if !reflect.DeepEqual(candidates, baselineCandidates) {
+		t.Errorf("plugins without data should not change the scores: got %v, want %v", candidates, baselineCandidates)
+	}
+	if !reflect.DeepEqual(elected, baselineElected) {
+		t.Errorf("plugins without data should not change the placement: got %v, want %v", elected, baselineElected)
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scheduler.yaml")
+	data := []byte(`
+filters:
+- name: WorkerReady
+- name: MemoryThreshold
+  args:
+    threshold: 50
+scores:
+- name: ResourceUsage
+  weight: 2
+  args:
+    cpuWeight: 0
+    memoryWeight: 1
+selection:
+  name: MaxScore
+`)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	framework, err := NewFramework(config, NewInTreeRegistry())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if threshold := framework.filters[1].(*MemoryThreshold).Threshold; threshold != 50 {
+		t.Errorf("expected threshold 50, got %f", threshold)
+	}
+	if weight := framework.scores[0].weight; weight != 2 {
+		t.Errorf("expected weight 2, got %f", weight)
+	}
+	if name := framework.selection.Name(); name != SelectionMaxScore {
+		t.Errorf("expected %s, got %s", SelectionMaxScore, name)
+	}
+}
+
+func TestNewFrameworkWithInvalidConfig(t *testing.T) {
+	for name, config := range map[string]*Config{
+		"unknown plugin": {
+			Filters: []PluginConfig{{Name: "Unknown"}},
+		},
+		"score plugin as filter": {
+			Filters: []PluginConfig{{Name: PluginResourceUsage}},
+		},
+		"filter plugin as selection": {
+			Selection: PluginConfig{Name: PluginWorkerReady},
+		},
+		"invalid args": {
+			Selection: PluginConfig{Name: SelectionBoltzmann, Args: Args{"temperature": 0}},
+		},
+	} {
+		if _, err := NewFramework(config, NewInTreeRegistry()); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package scheduler
+
+import (
+	"context"
+
+	"github.com/distribution/reference"
+
+	netconv1alpha1 "github.com/janog-netcon/netcon-problem-management-subsystem/api/v1alpha1"
+)
+
+const (
+	PluginImageLocality = "ImageLocality"
+)
+
+// ImageLocality prefers Workers which already have the images used by the topology,
+// as pulling large NOS images dominates the time to deploy ProblemEnvironments.
+type ImageLocality struct{}
+
+var (
+	_ ScorePlugin  = &ImageLocality{}
+	_ ScoreSkipper = &ImageLocality{}
+)
+
+func newImageLocality(_ Args) (Plugin, error) {
+	return &ImageLocality{}, nil
+}
+
+func (*ImageLocality) Name() string {
+	return PluginImageLocality
+}
+
+// SkipScore implements ScoreSkipper
+func (*ImageLocality) SkipScore(
+	state *CycleState,
+	problemEnvironment *netconv1alpha1.ProblemEnvironment,
+) bool {
+	return state.Topology == nil || len(state.Topology.Images()) == 0
+}
+
+// Score implements ScorePlugin
+func (*ImageLocality) Score(
+	ctx context.Context,
+	state *CycleState,
+	problemEnvironment *netconv1alpha1.ProblemEnvironment,
+	worker *netconv1alpha1.Worker,
+) float64 {
+	if state.Topology == nil {
+		return 0
+	}
+
+	images := state.Topology.Images()
+	if len(images) == 0 {
+		return 0
+	}
+
+	present := map[string]bool{}
+	for _, image := range worker.Status.Images {
+		for _, name := range image.Names {
+			present[normalizeImageName(name)] = true
+		}
+	}
+
+	found := 0
+	for _, image := range images {
+		if present[normalizeImageName(image)] {
+			found++
+		}
+	}
+
+	// Note: Score is scaled from 0 to 1, 1 when the Worker has all images
+	return float64(found) / float64(len(images))
+}
+
+// normalizeImageName normalizes the image name so that `alpine`, `alpine:latest` and
+// `docker.io/library/alpine:latest` are considered to be the same image.
+func normalizeImageName(name string) string {
+	named, err := reference.ParseNormalizedNamed(name)
+	if err != nil {
+		return name
+	}
+	return reference.FamiliarString(reference.TagNameOnly(named))
+}
@@ -0,0 +1,94 @@
+package scheduler
+
+import (
+	"context"
+
+	netconv1alpha1 "github.com/janog-netcon/netcon-problem-management-subsystem/api/v1alpha1"
+	"github.com/janog-netcon/netcon-problem-management-subsystem/pkg/containerlab"
+)
+
+// Plugin is the base interface of all scheduler plugins.
+type Plugin interface {
+	// Name returns the name used to refer the plugin from Config
+	Name() string
+}
+
+// FilterPlugin decides whether a Worker can host a ProblemEnvironment.
+type FilterPlugin interface {
+	Plugin
+
+	// Filter returns nil if the ProblemEnvironment can be scheduled on the Worker.
+	// Otherwise, it returns an error describing why the Worker was rejected.
+	Filter(
+		ctx context.Context,
+		state *CycleState,
+		problemEnvironment *netconv1alpha1.ProblemEnvironment,
+		worker *netconv1alpha1.Worker,
+	) error
+}
+
+// ScorePlugin ranks Workers which passed all FilterPlugins.
+type ScorePlugin interface {
+	Plugin
+
+	// Score returns the score of the Worker. Score must be scaled from 0 to 1.
+	Score(
+		ctx context.Context,
+		state *CycleState,
+		problemEnvironment *netconv1alpha1.ProblemEnvironment,
+		worker *netconv1alpha1.Worker,
+	) float64
+}
+
+// ScoreSkipper is optionally implemented by ScorePlugins which have nothing to
+// score for some ProblemEnvironments. Skipped ScorePlugins are excluded from
+// the weighted average so that they don't flatten the scores of other plugins.
+type ScoreSkipper interface {
+	// SkipScore returns true if the ScorePlugin should not score the ProblemEnvironment.
+	SkipScore(state *CycleState, problemEnvironment *netconv1alpha1.ProblemEnvironment) bool
+}
+
+// SelectionStrategy elects a Worker from scored candidates.
+type SelectionStrategy interface {
+	Plugin
+
+	// Select returns the name of the elected Worker. candidates is never empty.
+	// Strategies must draw random numbers only from random to make the election reproducible.
+	Select(candidates []Candidate, random RandomSource) string
+}
+
+// RandomSource is the source of random numbers used by SelectionStrategy.
+// *rand.Rand in math/rand/v2 satisfies RandomSource.
+type RandomSource interface {
+	// Float64 returns a pseudo-random number in the half-open interval [0.0,1.0).
+	Float64() float64
+}
+
+// Candidate is a Worker which passed all FilterPlugins.
+type Candidate struct {
+	// Name is the name of the worker.
+	Name string
+
+	// Score is the score of the worker (0..1).
+	Score float64
+}
+
+// CycleState is the snapshot of the cluster which plugins refer in a scheduling cycle.
+type CycleState struct {
+	// Workers is the list of all Workers
+	Workers []netconv1alpha1.Worker
+
+	// ProblemEnvironments is the list of all ProblemEnvironments, including ones not scheduled yet
+	ProblemEnvironments []netconv1alpha1.ProblemEnvironment
+
+	// Problems is the list of all Problems
+	Problems []netconv1alpha1.Problem
+
+	// Topology is the topology of the ProblemEnvironment being scheduled.
+	// It can be nil if the topology couldn't be loaded.
+	Topology *containerlab.Topology
+
+	// pendingDeployments caches pendingDeploymentsByWorker.
+	// It must be reset when ProblemEnvironments are changed.
+	pendingDeployments map[string]int
+}
@@ -0,0 +1,25 @@
+package scheduler
+
+// PluginFactory builds a Plugin from its arguments.
+type PluginFactory func(args Args) (Plugin, error)
+
+// Registry maps the name of plugins to their factories.
+type Registry map[string]PluginFactory
+
+// NewInTreeRegistry returns Registry containing all plugins in this package.
+func NewInTreeRegistry() Registry {
+	return Registry{
+		PluginWorkerReady:        newWorkerReady,
+		PluginWorkerSchedulable:  newWorkerSchedulable,
+		PluginWorkerSelector:     newWorkerSelector,
+		PluginMemoryThreshold:    newMemoryThreshold,
+		PluginResourceFit:        newResourceFit,
+		PluginTopologySpread:     newTopologySpread,
+		PluginTaintToleration:    newTaintToleration,
+		PluginResourceUsage:      newResourceUsage,
+		PluginPendingDeployments: newPendingDeployments,
+		PluginImageLocality:      newImageLocality,
+		SelectionBoltzmann:       newBoltzmann,
+		SelectionMaxScore:        newMaxScore,
+	}
+}
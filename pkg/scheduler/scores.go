@@ -0,0 +1,150 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	netconv1alpha1 "github.com/janog-netcon/netcon-problem-management-subsystem/api/v1alpha1"
+	"github.com/janog-netcon/netcon-problem-management-subsystem/pkg/util"
+)
+
+const (
+	PluginResourceUsage      = "ResourceUsage"
+	PluginPendingDeployments = "PendingDeployments"
+)
+
+// ResourceUsage prefers Workers with lower CPU and memory usage.
+type ResourceUsage struct {
+	CPUWeight    float64 `yaml:"cpuWeight"`
+	MemoryWeight float64 `yaml:"memoryWeight"`
+}
+
+var _ ScorePlugin = &ResourceUsage{}
+
+func newResourceUsage(args Args) (Plugin, error) {
+	plugin := &ResourceUsage{CPUWeight: 1.0, MemoryWeight: 3.0}
+	if err := args.Decode(plugin); err != nil {
+		return nil, err
+	}
+	if plugin.CPUWeight+plugin.MemoryWeight <= 0 {
+		return nil, errors.New("sum of cpuWeight and memoryWeight must be positive")
+	}
+	return plugin, nil
+}
+
+func (*ResourceUsage) Name() string {
+	return PluginResourceUsage
+}
+
+// Score implements ScorePlugin
+func (p *ResourceUsage) Score(
+	ctx context.Context,
+	state *CycleState,
+	problemEnvironment *netconv1alpha1.ProblemEnvironment,
+	worker *netconv1alpha1.Worker,
+) float64 {
+	cpuUsedPercent := parseUsedPercent(ctx, worker.Status.WorkerInfo.CPUUsedPercent, "CPUUsedPercent")
+	memoryUsedPercent := parseUsedPercent(ctx, worker.Status.WorkerInfo.MemoryUsedPercent, "MemoryUsedPercent")
+
+	// Note: Score is scaled from 0 to 1
+	cost := (cpuUsedPercent*p.CPUWeight + memoryUsedPercent*p.MemoryWeight)
+	cost = cost / (p.CPUWeight + p.MemoryWeight) / 100
+	return 1 - cost
+}
+
+// PendingDeployments penalizes Workers which have ProblemEnvironments scheduled
+// but not deployed yet. As CPU and memory usage reported by Workers are moving
+// averages, they don't reflect ProblemEnvironments being deployed right now.
+//
+// As a FilterPlugin, it also rejects Workers which already have MaxPending
+// pending deployments. MaxPending 0 means unlimited.
+type PendingDeployments struct {
+	MaxPending int `yaml:"maxPending"`
+}
+
+var (
+	_ FilterPlugin = &PendingDeployments{}
+	_ ScorePlugin  = &PendingDeployments{}
+	_ ScoreSkipper = &PendingDeployments{}
+)
+
+func newPendingDeployments(args Args) (Plugin, error) {
+	plugin := &PendingDeployments{}
+	if err := args.Decode(plugin); err != nil {
+		return nil, err
+	}
+	if plugin.MaxPending < 0 {
+		return nil, errors.New("maxPending must not be negative")
+	}
+	return plugin, nil
+}
+
+func (*PendingDeployments) Name() string {
+	return PluginPendingDeployments
+}
+
+// Filter implements FilterPlugin
+func (p *PendingDeployments) Filter(
+	ctx context.Context,
+	state *CycleState,
+	problemEnvironment *netconv1alpha1.ProblemEnvironment,
+	worker *netconv1alpha1.Worker,
+) error {
+	if p.MaxPending == 0 {
+		return nil
+	}
+	if pending := state.pendingDeploymentsByWorker()[worker.Name]; pending >= p.MaxPending {
+		return fmt.Errorf("worker has %d pending deployments (max %d)", pending, p.MaxPending)
+	}
+	return nil
+}
+
+// SkipScore implements ScoreSkipper
+func (*PendingDeployments) SkipScore(
+	state *CycleState,
+	problemEnvironment *netconv1alpha1.ProblemEnvironment,
+) bool {
+	return len(state.pendingDeploymentsByWorker()) == 0
+}
+
+// Score implements ScorePlugin
+func (*PendingDeployments) Score(
+	ctx context.Context,
+	state *CycleState,
+	problemEnvironment *netconv1alpha1.ProblemEnvironment,
+	worker *netconv1alpha1.Worker,
+) float64 {
+	// Note: Score is scaled from 0 to 1, 1 when there is no pending deployment
+	return 1 / float64(1+state.pendingDeploymentsByWorker()[worker.Name])
+}
+
+// pendingDeploymentsByWorker returns the number of ProblemEnvironments which are
+// scheduled but not deployed yet, keyed by the name of Worker. It's computed once
+// per CycleState, as Filter and Score are called for each Worker.
+func (s *CycleState) pendingDeploymentsByWorker() map[string]int {
+	if s.pendingDeployments != nil {
+		return s.pendingDeployments
+	}
+
+	pending := map[string]int{}
+	for i := range s.ProblemEnvironments {
+		pe := &s.ProblemEnvironments[i]
+		if pe.Spec.WorkerName == "" || pe.DeletionTimestamp != nil {
+			continue
+		}
+		if util.GetProblemEnvironmentCondition(pe, netconv1alpha1.ProblemEnvironmentConditionDeployed) == metav1.ConditionTrue {
+			continue
+		}
+		// nclet gave up deploying, so it will never be deployed
+		if util.GetProblemEnvironmentCondition(pe, netconv1alpha1.ProblemEnvironmentConditionFailed) == metav1.ConditionTrue {
+			continue
+		}
+		pending[pe.Spec.WorkerName]++
+	}
+
+	s.pendingDeployments = pending
+	return pending
+}
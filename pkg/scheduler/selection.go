@@ -0,0 +1,112 @@
+package scheduler
+
+import (
+	"errors"
+	"math"
+	"math/rand/v2"
+	"sync"
+)
+
+// globalRandomSource draws random numbers from the global source of math/rand/v2.
+type globalRandomSource struct{}
+
+func (globalRandomSource) Float64() float64 {
+	return rand.Float64()
+}
+
+// seededRandomSource draws random numbers from the source initialized with the seed.
+// rand.Rand isn't safe for concurrent use, so it's guarded by mutex.
+type seededRandomSource struct {
+	mu     sync.Mutex
+	random *rand.Rand
+}
+
+// NewSeededRandomSource returns RandomSource which generates the same sequence
+// for the same seed. It's safe for concurrent use.
+func NewSeededRandomSource(seed uint64) RandomSource {
+	return &seededRandomSource{random: rand.New(rand.NewPCG(seed, seed))}
+}
+
+func (s *seededRandomSource) Float64() float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.random.Float64()
+}
+
+const (
+	SelectionBoltzmann = "Boltzmann"
+	SelectionMaxScore  = "MaxScore"
+)
+
+// Boltzmann elects a Worker at random with the Boltzmann distribution of the scores.
+// The lower Temperature is, the more likely the Worker with the highest score is elected.
+type Boltzmann struct {
+	Temperature float64 `yaml:"temperature"`
+}
+
+var _ SelectionStrategy = &Boltzmann{}
+
+func newBoltzmann(args Args) (Plugin, error) {
+	plugin := &Boltzmann{Temperature: 0.1}
+	if err := args.Decode(plugin); err != nil {
+		return nil, err
+	}
+	if plugin.Temperature <= 0 {
+		return nil, errors.New("temperature must be positive")
+	}
+	return plugin, nil
+}
+
+func (*Boltzmann) Name() string {
+	return SelectionBoltzmann
+}
+
+// Select implements SelectionStrategy
+func (p *Boltzmann) Select(candidates []Candidate, random RandomSource) string {
+	totalCandidates := len(candidates)
+
+	tmps := make([]float64, totalCandidates)
+	for i := range totalCandidates {
+		tmps[i] = math.Exp(candidates[i].Score / p.Temperature)
+	}
+
+	total := 0.0
+	for i := range totalCandidates {
+		total += tmps[i]
+	}
+
+	v := random.Float64()
+	for i := range totalCandidates {
+		v -= tmps[i] / total
+		if v < 0 {
+			return candidates[i].Name
+		}
+	}
+
+	return candidates[totalCandidates-1].Name
+}
+
+// MaxScore always elects the Worker with the highest score.
+// If some Workers have the same score, the first one is elected.
+type MaxScore struct{}
+
+var _ SelectionStrategy = &MaxScore{}
+
+func newMaxScore(_ Args) (Plugin, error) {
+	return &MaxScore{}, nil
+}
+
+func (*MaxScore) Name() string {
+	return SelectionMaxScore
+}
+
+// Select implements SelectionStrategy
+func (*MaxScore) Select(candidates []Candidate, _ RandomSource) string {
+	elected := candidates[0]
+	for _, candidate := range candidates[1:] {
+		if candidate.Score > elected.Score {
+			elected = candidate
+		}
+	}
+	return elected.Name
+}
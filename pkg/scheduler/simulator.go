@@ -0,0 +1,87 @@
+package scheduler
+
+import (
+	"context"
+
+	netconv1alpha1 "github.com/janog-netcon/netcon-problem-management-subsystem/api/v1alpha1"
+	"github.com/janog-netcon/netcon-problem-management-subsystem/pkg/containerlab"
+)
+
+// Placement is the result of scheduling a ProblemEnvironment in Simulator.
+type Placement struct {
+	ProblemEnvironment netconv1alpha1.ProblemEnvironment
+
+	// WorkerName is the name of the elected Worker, or empty if it's unschedulable
+	WorkerName string
+
+	// Diagnosis holds the reason why each Worker was rejected
+	Diagnosis Diagnosis
+}
+
+// Simulator schedules ProblemEnvironments one by one against the snapshot of the cluster
+// without creating anything. ProblemEnvironments placed by Simulator are added to
+// the snapshot, so that the following placements take them into account as well as
+// the real scheduling cycles do.
+type Simulator struct {
+	framework *Framework
+	state     CycleState
+}
+
+// NewSimulator returns Simulator. state is copied not to modify the original one.
+func NewSimulator(framework *Framework, state CycleState) *Simulator {
+	state.ProblemEnvironments = append([]netconv1alpha1.ProblemEnvironment{}, state.ProblemEnvironments...)
+	state.pendingDeployments = nil
+	return &Simulator{
+		framework: framework,
+		state:     state,
+	}
+}
+
+// Simulate schedules problemEnvironments in order. topologyOf returns the topology
+// of each ProblemEnvironment, which can be nil.
+func (s *Simulator) Simulate(
+	ctx context.Context,
+	problemEnvironments []netconv1alpha1.ProblemEnvironment,
+	topologyOf func(*netconv1alpha1.ProblemEnvironment) *containerlab.Topology,
+) []Placement {
+	placements := []Placement{}
+
+	for i := range problemEnvironments {
+		problemEnvironment := problemEnvironments[i].DeepCopy()
+
+		s.state.Topology = topologyOf(problemEnvironment)
+		workerName, diagnosis := s.framework.Schedule(ctx, &s.state, problemEnvironment)
+
+		if workerName != "" {
+			problemEnvironment.Spec.WorkerName = workerName
+			s.place(problemEnvironment)
+		}
+
+		placements = append(placements, Placement{
+			ProblemEnvironment: *problemEnvironment,
+			WorkerName:         workerName,
+			Diagnosis:          diagnosis,
+		})
+	}
+
+	return placements
+}
+
+// place adds the ProblemEnvironment to the snapshot, or updates it if it already exists.
+func (s *Simulator) place(problemEnvironment *netconv1alpha1.ProblemEnvironment) {
+	s.state.pendingDeployments = nil
+
+	for i := range s.state.ProblemEnvironments {
+		pe := &s.state.ProblemEnvironments[i]
+		if pe.Namespace == problemEnvironment.Namespace && pe.Name == problemEnvironment.Name {
+			*pe = *problemEnvironment
+			return
+		}
+	}
+	s.state.ProblemEnvironments = append(s.state.ProblemEnvironments, *problemEnvironment)
+}
+
+// State returns the snapshot of the cluster including placed ProblemEnvironments.
+func (s *Simulator) State() *CycleState {
+	return &s.state
+}
@@ -0,0 +1,98 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+
+	netconv1alpha1 "github.com/janog-netcon/netcon-problem-management-subsystem/api/v1alpha1"
+)
+
+const (
+	PluginTaintToleration = "TaintToleration"
+)
+
+// TaintToleration keeps ProblemEnvironments away from Workers with taints they don't tolerate.
+//
+// As a FilterPlugin, it rejects Workers with untolerated NoSchedule or NoExecute taints.
+// As a ScorePlugin, it prefers Workers with fewer untolerated PreferNoSchedule taints.
+type TaintToleration struct{}
+
+var (
+	_ FilterPlugin = &TaintToleration{}
+	_ ScorePlugin  = &TaintToleration{}
+	_ ScoreSkipper = &TaintToleration{}
+)
+
+func newTaintToleration(_ Args) (Plugin, error) {
+	return &TaintToleration{}, nil
+}
+
+func (*TaintToleration) Name() string {
+	return PluginTaintToleration
+}
+
+// Filter implements FilterPlugin
+func (*TaintToleration) Filter(
+	ctx context.Context,
+	state *CycleState,
+	problemEnvironment *netconv1alpha1.ProblemEnvironment,
+	worker *netconv1alpha1.Worker,
+) error {
+	for i := range worker.Spec.Taints {
+		taint := &worker.Spec.Taints[i]
+		if taint.Effect == corev1.TaintEffectPreferNoSchedule {
+			continue
+		}
+		if !toleratesTaint(problemEnvironment.Spec.Tolerations, taint) {
+			return fmt.Errorf("worker has untolerated taint %s", taint.ToString())
+		}
+	}
+	return nil
+}
+
+// SkipScore implements ScoreSkipper
+func (*TaintToleration) SkipScore(
+	state *CycleState,
+	problemEnvironment *netconv1alpha1.ProblemEnvironment,
+) bool {
+	for i := range state.Workers {
+		for _, taint := range state.Workers[i].Spec.Taints {
+			if taint.Effect == corev1.TaintEffectPreferNoSchedule {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Score implements ScorePlugin
+func (*TaintToleration) Score(
+	ctx context.Context,
+	state *CycleState,
+	problemEnvironment *netconv1alpha1.ProblemEnvironment,
+	worker *netconv1alpha1.Worker,
+) float64 {
+	untolerated := 0
+	for i := range worker.Spec.Taints {
+		taint := &worker.Spec.Taints[i]
+		if taint.Effect != corev1.TaintEffectPreferNoSchedule {
+			continue
+		}
+		if !toleratesTaint(problemEnvironment.Spec.Tolerations, taint) {
+			untolerated++
+		}
+	}
+	// Note: Score is scaled from 0 to 1, 1 when all taints are tolerated
+	return 1 / float64(1+untolerated)
+}
+
+func toleratesTaint(tolerations []corev1.Toleration, taint *corev1.Taint) bool {
+	for i := range tolerations {
+		if tolerations[i].ToleratesTaint(taint) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,207 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	netconv1alpha1 "github.com/janog-netcon/netcon-problem-management-subsystem/api/v1alpha1"
+	"github.com/janog-netcon/netcon-problem-management-subsystem/pkg/util"
+)
+
+const (
+	PluginTopologySpread = "TopologySpread"
+)
+
+// TopologySpread spreads ProblemEnvironments of the same Problem across domains
+// following TopologySpreadConstraints of the Problem.
+//
+// As a FilterPlugin, it rejects Workers which violate constraints with DoNotSchedule.
+// As a ScorePlugin, it prefers Workers in the domains with fewer ProblemEnvironments.
+type TopologySpread struct{}
+
+var (
+	_ FilterPlugin = &TopologySpread{}
+	_ ScorePlugin  = &TopologySpread{}
+	_ ScoreSkipper = &TopologySpread{}
+)
+
+func newTopologySpread(_ Args) (Plugin, error) {
+	return &TopologySpread{}, nil
+}
+
+func (*TopologySpread) Name() string {
+	return PluginTopologySpread
+}
+
+// Filter implements FilterPlugin
+func (*TopologySpread) Filter(
+	ctx context.Context,
+	state *CycleState,
+	problemEnvironment *netconv1alpha1.ProblemEnvironment,
+	worker *netconv1alpha1.Worker,
+) error {
+	for _, constraint := range spreadConstraintsFor(state, problemEnvironment) {
+		if constraint.WhenUnsatisfiable == netconv1alpha1.ScheduleAnyway {
+			continue
+		}
+
+		domain, ok := worker.Labels[constraint.TopologyKey]
+		if !ok {
+			return fmt.Errorf("worker doesn't have label %s", constraint.TopologyKey)
+		}
+
+		counts := countInDomains(ctx, state, problemEnvironment, constraint.TopologyKey)
+		if skew := counts.skew(domain); skew > constraint.MaxSkew {
+			return fmt.Errorf(
+				"skew %d in %s=%s exceeds maxSkew %d",
+				skew, constraint.TopologyKey, domain, constraint.MaxSkew,
+			)
+		}
+	}
+	return nil
+}
+
+// SkipScore implements ScoreSkipper
+func (*TopologySpread) SkipScore(
+	state *CycleState,
+	problemEnvironment *netconv1alpha1.ProblemEnvironment,
+) bool {
+	return len(spreadConstraintsFor(state, problemEnvironment)) == 0
+}
+
+// Score implements ScorePlugin
+func (*TopologySpread) Score(
+	ctx context.Context,
+	state *CycleState,
+	problemEnvironment *netconv1alpha1.ProblemEnvironment,
+	worker *netconv1alpha1.Worker,
+) float64 {
+	constraints := spreadConstraintsFor(state, problemEnvironment)
+	if len(constraints) == 0 {
+		return 0
+	}
+
+	total := 0.0
+	for _, constraint := range constraints {
+		domain, ok := worker.Labels[constraint.TopologyKey]
+		if !ok {
+			continue
+		}
+
+		counts := countInDomains(ctx, state, problemEnvironment, constraint.TopologyKey)
+		// Note: Score is scaled from 0 to 1, 1 when the domain has the fewest ProblemEnvironments
+		total += 1 / float64(max(counts.skew(domain), 1))
+	}
+	return total / float64(len(constraints))
+}
+
+// spreadConstraintsFor returns TopologySpreadConstraints of the Problem
+// which the ProblemEnvironment belongs to.
+func spreadConstraintsFor(
+	state *CycleState,
+	problemEnvironment *netconv1alpha1.ProblemEnvironment,
+) []netconv1alpha1.TopologySpreadConstraint {
+	problemName, ok := problemEnvironment.Labels[netconv1alpha1.LabelProblemName]
+	if !ok {
+		return nil
+	}
+
+	for i := range state.Problems {
+		problem := &state.Problems[i]
+		if problem.Namespace == problemEnvironment.Namespace && problem.Name == problemName {
+			return problem.Spec.TopologySpreadConstraints
+		}
+	}
+	return nil
+}
+
+// domainCounts holds the number of ProblemEnvironments in each domain.
+type domainCounts struct {
+	counts map[string]int
+
+	// eligible is the set of domains where the ProblemEnvironment can be scheduled.
+	// Only eligible domains are considered to calculate the minimum.
+	eligible map[string]bool
+}
+
+// skew returns the skew when a ProblemEnvironment is added to the domain.
+func (c *domainCounts) skew(domain string) int {
+	minCount := -1
+	for d := range c.eligible {
+		if minCount == -1 || c.counts[d] < minCount {
+			minCount = c.counts[d]
+		}
+	}
+	if minCount == -1 {
+		minCount = 0
+	}
+	return c.counts[domain] + 1 - minCount
+}
+
+// countInDomains counts ProblemEnvironments of the same Problem in each domain
+// distinguished by the Worker label topologyKey.
+func countInDomains(
+	ctx context.Context,
+	state *CycleState,
+	problemEnvironment *netconv1alpha1.ProblemEnvironment,
+	topologyKey string,
+) *domainCounts {
+	c := &domainCounts{
+		counts:   map[string]int{},
+		eligible: map[string]bool{},
+	}
+
+	domainOf := map[string]string{}
+	for i := range state.Workers {
+		worker := &state.Workers[i]
+
+		domain, ok := worker.Labels[topologyKey]
+		if !ok {
+			continue
+		}
+		domainOf[worker.Name] = domain
+
+		if isEligibleForSpread(ctx, problemEnvironment, worker) {
+			c.eligible[domain] = true
+		}
+	}
+
+	problemName := problemEnvironment.Labels[netconv1alpha1.LabelProblemName]
+	for i := range state.ProblemEnvironments {
+		pe := &state.ProblemEnvironments[i]
+		if pe.Namespace != problemEnvironment.Namespace || pe.Name == problemEnvironment.Name {
+			continue
+		}
+		if pe.Labels[netconv1alpha1.LabelProblemName] != problemName || pe.DeletionTimestamp != nil {
+			continue
+		}
+
+		if domain, ok := domainOf[pe.Spec.WorkerName]; ok {
+			c.counts[domain]++
+		}
+	}
+
+	return c
+}
+
+// isEligibleForSpread returns true if the Worker can host the ProblemEnvironment
+// regardless of its resource usage. Domains only with ineligible Workers are
+// ignored not to block scheduling forever.
+func isEligibleForSpread(
+	ctx context.Context,
+	problemEnvironment *netconv1alpha1.ProblemEnvironment,
+	worker *netconv1alpha1.Worker,
+) bool {
+	if util.GetWorkerCondition(worker, netconv1alpha1.WorkerConditionReady) != metav1.ConditionTrue {
+		return false
+	}
+	if worker.Spec.DisableSchedule {
+		return false
+	}
+	if err := (&TaintToleration{}).Filter(ctx, nil, problemEnvironment, worker); err != nil {
+		return false
+	}
+	return matchesWorkerSelectors(ctx, problemEnvironment, worker)
+}
@@ -0,0 +1,77 @@
+package util
+
+import (
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	netconv1alpha1 "github.com/janog-netcon/netcon-problem-management-subsystem/api/v1alpha1"
+)
+
+// GetAcquisitionWindow returns the window to count acquisitions of the Problem.
+func GetAcquisitionWindow(problem *netconv1alpha1.Problem) time.Duration {
+	if autoscaling := problem.Spec.Autoscaling; autoscaling != nil && autoscaling.Window != nil {
+		if autoscaling.Window.Duration > 0 {
+			return autoscaling.Window.Duration
+		}
+	}
+	return netconv1alpha1.DefaultAcquisitionWindow
+}
+
+// shiftAcquisitionWindow moves the current window forward to now if it has expired.
+func shiftAcquisitionWindow(acquisitions *netconv1alpha1.ProblemAcquisitions, window time.Duration, now time.Time) {
+	if acquisitions.WindowStart == nil {
+		return
+	}
+
+	elapsed := now.Sub(acquisitions.WindowStart.Time)
+	if elapsed < window {
+		return
+	}
+
+	if elapsed < 2*window {
+		acquisitions.PreviousCount = acquisitions.Count
+	} else {
+		acquisitions.PreviousCount = 0
+	}
+	acquisitions.Count = 0
+
+	start := metav1.NewTime(acquisitions.WindowStart.Add(elapsed / window * window))
+	acquisitions.WindowStart = &start
+}
+
+// RecordProblemAcquisition counts an acquisition of ProblemEnvironment of the Problem.
+func RecordProblemAcquisition(problem *netconv1alpha1.Problem, now time.Time) {
+	acquisitions := &problem.Status.Acquisitions
+	if acquisitions.WindowStart == nil {
+		start := metav1.NewTime(now)
+		acquisitions.WindowStart = &start
+	}
+
+	shiftAcquisitionWindow(acquisitions, GetAcquisitionWindow(problem), now)
+	acquisitions.Count++
+}
+
+// EstimateProblemAcquisitions estimates the number of acquisitions in the last window
+// with the sliding window, weighting the previous window by the part overlapping it.
+func EstimateProblemAcquisitions(problem *netconv1alpha1.Problem, now time.Time) float64 {
+	acquisitions := problem.Status.Acquisitions.DeepCopy()
+	if acquisitions.WindowStart == nil {
+		return 0
+	}
+
+	window := GetAcquisitionWindow(problem)
+	shiftAcquisitionWindow(acquisitions, window, now)
+
+	elapsed := max(now.Sub(acquisitions.WindowStart.Time), 0)
+	overlap := 1 - float64(elapsed)/float64(window)
+	return float64(acquisitions.PreviousCount)*overlap + float64(acquisitions.Count)
+}
+
+// GetAssignableReplicas returns the number of assignable ProblemEnvironments the Problem desires.
+func GetAssignableReplicas(problem *netconv1alpha1.Problem) int {
+	if problem.Spec.Autoscaling != nil {
+		return problem.Status.DesiredReplicas
+	}
+	return problem.Spec.AssignableReplicas
+}
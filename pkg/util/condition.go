@@ -60,6 +60,20 @@ func GetProblemEnvironmentCondition(
 	return metav1.ConditionUnknown
 }
 
+// FindProblemEnvironmentCondition returns the condition of the ProblemEnvironment, or nil if it's not found.
+func FindProblemEnvironmentCondition(
+	problemEnvironment *netconv1alpha1.ProblemEnvironment,
+	conditionType netconv1alpha1.ProblemEnvironmentConditionType,
+) *metav1.Condition {
+	for i := range problemEnvironment.Status.Conditions {
+		condition := &problemEnvironment.Status.Conditions[i]
+		if condition.Type == string(conditionType) {
+			return condition
+		}
+	}
+	return nil
+}
+
 func SetWorkerCondition(
 	worker *netconv1alpha1.Worker,
 	conditionType netconv1alpha1.WorkerConditionType,
@@ -111,3 +125,69 @@ func GetWorkerCondition(
 	}
 	return metav1.ConditionUnknown
 }
+
+// FindWorkerCondition returns the condition of the Worker, or nil if it's not found.
+func FindWorkerCondition(
+	worker *netconv1alpha1.Worker,
+	conditionType netconv1alpha1.WorkerConditionType,
+) *metav1.Condition {
+	for i := range worker.Status.Conditions {
+		condition := &worker.Status.Conditions[i]
+		if condition.Type == string(conditionType) {
+			return condition
+		}
+	}
+	return nil
+}
+
+func SetProblemCondition(
+	problem *netconv1alpha1.Problem,
+	conditionType netconv1alpha1.ProblemConditionType,
+	status metav1.ConditionStatus,
+	reason, message string,
+) {
+	now := metav1.NewTime(time.Now())
+
+	for i := range problem.Status.Conditions {
+		condition := &problem.Status.Conditions[i]
+		if condition.Type != string(conditionType) {
+			continue
+		}
+
+		if condition.Status != status {
+			condition.Status = status
+			condition.LastTransitionTime = now
+		}
+
+		condition.ObservedGeneration = problem.ObjectMeta.Generation
+		condition.Reason = reason
+		condition.Message = message
+
+		return
+	}
+
+	conditions := append(problem.Status.Conditions, metav1.Condition{
+		Type:               string(conditionType),
+		Status:             status,
+		ObservedGeneration: problem.ObjectMeta.Generation,
+		LastTransitionTime: now,
+		Reason:             reason,
+		Message:            message,
+	})
+
+	problem.Status.Conditions = conditions
+}
+
+func GetProblemCondition(
+	problem *netconv1alpha1.Problem,
+	conditionType netconv1alpha1.ProblemConditionType,
+) metav1.ConditionStatus {
+	for i := range problem.Status.Conditions {
+		condition := &problem.Status.Conditions[i]
+		if condition.Type != string(conditionType) {
+			continue
+		}
+		return condition.Status
+	}
+	return metav1.ConditionUnknown
+}
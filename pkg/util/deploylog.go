@@ -0,0 +1,23 @@
+package util
+
+import (
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// SortDeployLogs sorts the deploy logs recorded by nclet as ConfigMaps, newest first.
+//
+// They are sorted by creationTimestamp instead of LabelDeployAttempt, as the attempt is
+// counted again after ProblemEnvironment is reset and doesn't identify the deploy log.
+// Deploy logs created in the same second are sorted by name, which contains
+// the unix time when deploying started.
+func SortDeployLogs(configMaps []corev1.ConfigMap) {
+	sort.Slice(configMaps, func(i, j int) bool {
+		a, b := configMaps[i].CreationTimestamp, configMaps[j].CreationTimestamp
+		if !a.Equal(&b) {
+			return b.Before(&a)
+		}
+		return configMaps[i].Name > configMaps[j].Name
+	})
+}
@@ -0,0 +1,25 @@
+package util
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	netconv1alpha1 "github.com/janog-netcon/netcon-problem-management-subsystem/api/v1alpha1"
+)
+
+// RequestProblemEnvironmentReset bumps ResetGeneration so that nclet resets the ProblemEnvironment.
+func RequestProblemEnvironmentReset(problemEnvironment *netconv1alpha1.ProblemEnvironment) {
+	problemEnvironment.Spec.ResetGeneration++
+}
+
+// IsProblemEnvironmentResetRequested returns true if nclet hasn't started to reset the ProblemEnvironment yet.
+func IsProblemEnvironmentResetRequested(problemEnvironment *netconv1alpha1.ProblemEnvironment) bool {
+	return problemEnvironment.Spec.ResetGeneration != problemEnvironment.Status.ObservedResetGeneration
+}
+
+// IsProblemEnvironmentResetting returns true if the reset of the ProblemEnvironment is requested or in progress.
+func IsProblemEnvironmentResetting(problemEnvironment *netconv1alpha1.ProblemEnvironment) bool {
+	return IsProblemEnvironmentResetRequested(problemEnvironment) || GetProblemEnvironmentCondition(
+		problemEnvironment,
+		netconv1alpha1.ProblemEnvironmentConditionResetting,
+	) == metav1.ConditionTrue
+}